@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/tassa-yoniso-manasi-karoto/giita/pkg/libgiita"
+)
+
+type jobType struct {
+	in, out string
+	doc     Document
+	err     *exitError
+	// found by walking a directory rather than named on the command line
+	walked bool
+}
+
+// batch processes all the files designated by args (files, directories or
+// glob patterns) concurrently and mirrors them into the directory outDir.
+// It returns the exit code of the first failure, if any.
+func batch(args []string, outDir string, opts Options) int {
+	start := time.Now()
+	jobs, err := collectJobs(args, outDir, *wantFormat)
+	if err != nil {
+		die(exitInput, err)
+	}
+	if len(jobs) == 0 {
+		die(exitInput, errors.New("No input file found."))
+	}
+	fmt.Fprintf(os.Stderr, "Processing %d file(s) into %s\n", len(jobs), outDir)
+	queue := make(chan *jobType)
+	var wg sync.WaitGroup
+	for i := 0; i < *wantJobs || i == 0; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				if job.err != nil {
+					continue
+				}
+				if err := os.MkdirAll(filepath.Dir(job.out), 0755); err != nil {
+					job.err = &exitError{exitWrite, fmt.Errorf("Could not create the output directory: %w", err)}
+					continue
+				}
+				job.doc, job.err = convert(job.in, job.out, opts)
+			}
+		}()
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+	return summarize(jobs, time.Since(start))
+}
+
+// collectJobs resolves args into input files and their mirrored output path
+// with the extension format. Only .txt and .json files are picked up from
+// directories, except those in outDir and those written by the jobs.
+func collectJobs(args []string, outDir, format string) (jobs []*jobType, err error) {
+	seen := make(map[string]*jobType)
+	add := func(in, rel string, walked bool) {
+		out := filepath.Join(outDir, strings.TrimSuffix(rel, filepath.Ext(rel))+"."+format)
+		job := &jobType{in: in, out: out, walked: walked}
+		if absPath(out) == absPath(in) {
+			job.err = &exitError{exitUsage, errors.New("Output would overwrite the input file")}
+		} else if prev, ok := seen[out]; ok {
+			job.err = &exitError{exitUsage, fmt.Errorf("Output file %s already produced from %s", out, prev.in)}
+		} else {
+			seen[out] = job
+		}
+		jobs = append(jobs, job)
+	}
+	for _, arg := range args {
+		matches := []string{arg}
+		if _, err := os.Stat(arg); err != nil && strings.ContainsAny(arg, "*?[") {
+			if matches, err = filepath.Glob(arg); err != nil {
+				return nil, fmt.Errorf("Invalid pattern %s: %w", arg, err)
+			}
+		}
+		for _, match := range matches {
+			fi, err := os.Stat(match)
+			if errors.Is(err, fs.ErrNotExist) {
+				err = errors.New("Input file does not exist.")
+			}
+			if err != nil {
+				jobs = append(jobs, &jobType{in: match, err: &exitError{exitInput, err}})
+				continue
+			}
+			if !fi.IsDir() {
+				add(match, filepath.Base(match), false)
+				continue
+			}
+			err = filepath.WalkDir(match, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				// the outputs of a previous run
+				if d.IsDir() && p != match && absPath(p) == absPath(outDir) {
+					return fs.SkipDir
+				}
+				if ext := strings.ToLower(filepath.Ext(p)); d.IsDir() || ext != ".txt" && ext != ".json" {
+					return nil
+				}
+				rel, err := filepath.Rel(match, p)
+				if err == nil {
+					add(p, rel, true)
+				}
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	// the files the run writes, e.g. when outDir is the input directory
+	written := make(map[string]*jobType)
+	for out, job := range seen {
+		written[absPath(out)] = job
+	}
+	kept := jobs[:0]
+	for _, job := range jobs {
+		if by, ok := written[absPath(job.in)]; job.walked && ok && by != job {
+			continue
+		}
+		kept = append(kept, job)
+	}
+	return kept, nil
+}
+
+func summarize(jobs []*jobType, elapsed time.Duration) (code int) {
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].in < jobs[j].in })
+	var warnings, failures []string
+	for _, job := range jobs {
+		if job.err != nil {
+			failures = append(failures, fmt.Sprintf("  %s: %v", job.in, job.err))
+			if code == exitOK {
+				code = job.err.code
+			}
+		} else if job.doc.Ellipses > 0 {
+			warnings = append(warnings, fmt.Sprintf("  %s: %d occurence(s) of '...' or '…', "+
+				"the chanting text could be incomplete", job.in, job.doc.Ellipses))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Processed %d/%d file(s) in %s\n", len(jobs)-len(failures), len(jobs), elapsed.Round(time.Millisecond))
+	if len(warnings) > 0 {
+		fmt.Fprintf(os.Stderr, "%sWarnings:\n%s%s\n", Orange, strings.Join(warnings, "\n"), ANSIReset)
+	}
+	if len(failures) > 0 {
+		fmt.Fprintf(os.Stderr, "Failures:\n%s\n", strings.Join(failures, "\n"))
+	}
+	return
+}
+
+func absPath(p string) string {
+	abs, _ := filepath.Abs(p)
+	return abs
+}
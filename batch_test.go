@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/tassa-yoniso-manasi-karoto/giita/pkg/libgiita"
+)
+
+// writeFiles creates the given files, with a short Pali text, under dir
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("namo tassa"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// ins returns the inputs of the jobs relative to dir
+func ins(t *testing.T, dir string, jobs []*jobType) string {
+	t.Helper()
+	var s []string
+	for _, job := range jobs {
+		rel, err := filepath.Rel(dir, job.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s = append(s, filepath.ToSlash(rel))
+	}
+	return strings.Join(s, " ")
+}
+
+func TestCollectJobsOwnOutput(t *testing.T) {
+	dir := t.TempDir()
+	// the outputs of a previous run of giita -t -o out .
+	writeFiles(t, dir, "a.txt", "sub/b.txt", "out/a.txt", "out/sub/b.txt")
+	jobs, err := collectJobs([]string{dir}, filepath.Join(dir, "out"), "txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ins(t, dir, jobs), "a.txt sub/b.txt"; got != want {
+		t.Errorf("inputs = %q, want %q", got, want)
+	}
+
+	// the output directory is the input directory: a.json is written by the run
+	dir = t.TempDir()
+	writeFiles(t, dir, "a.txt", "a.json", "c.json")
+	jobs, err = collectJobs([]string{dir}, dir, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ins(t, dir, jobs), "a.txt c.json"; got != want {
+		t.Errorf("inputs = %q, want %q", got, want)
+	}
+}
+
+// describe returns the jobs as "input>output:code", relative to dir
+func describe(t *testing.T, dir string, jobs []*jobType) string {
+	t.Helper()
+	var s []string
+	for _, job := range jobs {
+		code := exitOK
+		if job.err != nil {
+			code = job.err.code
+		}
+		in, _ := filepath.Rel(dir, job.in)
+		out := ""
+		if job.out != "" {
+			out, _ = filepath.Rel(dir, job.out)
+		}
+		s = append(s, fmt.Sprintf("%s>%s:%d", filepath.ToSlash(in), filepath.ToSlash(out), code))
+	}
+	return strings.Join(s, " ")
+}
+
+func TestCollectJobs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.txt", "b.json", "c.md", "sub/a.txt", "sub/d.txt")
+	tests := []struct {
+		args    []string
+		outDir  string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{[]string{"a.txt"}, "out", "htm", "a.txt>out/a.htm:0", false},
+		{[]string{"x.txt"}, "out", "htm", "x.txt>:3", false},
+		{[]string{"*.txt"}, "out", "htm", "a.txt>out/a.htm:0", false},
+		{[]string{"["}, "out", "htm", "", true},
+		{[]string{"."}, "out", "txt", "a.txt>out/a.txt:0 b.json>out/b.txt:0 sub/a.txt>out/sub/a.txt:0 sub/d.txt>out/sub/d.txt:0", false},
+		// the output would overwrite the input
+		{[]string{"a.txt"}, ".", "txt", "a.txt>a.txt:2", false},
+		// both files are mirrored to out/a.htm
+		{[]string{"a.txt", "sub/a.txt"}, "out", "htm", "a.txt>out/a.htm:0 sub/a.txt>out/a.htm:2", false},
+	}
+	for _, test := range tests {
+		var args []string
+		for _, arg := range test.args {
+			args = append(args, filepath.Join(dir, arg))
+		}
+		got, err := collectJobs(args, filepath.Join(dir, test.outDir), test.format)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: error %v, want error %v", test.args, err, test.wantErr)
+			continue
+		}
+		if s := describe(t, dir, got); s != test.want {
+			t.Errorf("%v: jobs = %q, want %q", test.args, s, test.want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		jobs []*jobType
+		want int
+	}{
+		{nil, exitOK},
+		{[]*jobType{{in: "a"}, {in: "b", doc: Document{Ellipses: 1}}}, exitOK},
+		// the code of the first failure in the order of the inputs
+		{[]*jobType{{in: "c", err: &exitError{exitWrite, errors.New("c")}}, {in: "b"},
+			{in: "a", err: &exitError{exitInput, errors.New("a")}}}, exitInput},
+	}
+	for i, test := range tests {
+		if got := summarize(test.jobs, 0); got != test.want {
+			t.Errorf("%d: code %d, want %d", i, got, test.want)
+		}
+	}
+}
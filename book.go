@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "github.com/tassa-yoniso-manasi-karoto/giita/pkg/libgiita"
+)
+
+// ManifestType describes a chanting book. Paths are relative to the manifest.
+//
+//	{
+//	  "title": "Chanting book",
+//	  "chapters": [
+//	    {"title": "Homage", "file": "namo.txt"},
+//	    {"title": "Ratana Sutta", "file": "ratana.txt", "options": {"hint": 0, "comments": "[:]"}}
+//	  ]
+//	}
+type ManifestType struct {
+	Title    string        `json:"title"`
+	Output   string        `json:"output"`
+	Chapters []ChapterType `json:"chapters"`
+}
+
+type ChapterType struct {
+	Title   string             `json:"title"`
+	File    string             `json:"file"`
+	Options ChapterOptionsType `json:"options"`
+}
+
+// ChapterOptionsType overrides the options given on the command line for a single chapter
+type ChapterOptionsType struct {
+	Hint        *float64 `json:"hint"`
+	Comments    *string  `json:"comments"`
+	Re          *string  `json:"re"`
+	Thai        *int     `json:"th"`
+	Script      *string  `json:"script"`
+	Scheme      *string  `json:"scheme"`
+	OptionalLow *bool    `json:"optionallow"`
+	Exceptions  string   `json:"exceptions"`
+	Formulas    string   `json:"formulas"`
+	Pe          *bool    `json:"pe"`
+	HintConfig  *string  `json:"hintcfg"`
+}
+
+// book assembles the chapters listed in the manifest into a single HTML document.
+func book(manifestPath string, opts Options) {
+	dat, err := os.ReadFile(manifestPath)
+	if err != nil {
+		die(exitInput, fmt.Errorf("Could not read the manifest: %w", err))
+	}
+	var manifest ManifestType
+	if err = json.Unmarshal(dat, &manifest); err != nil {
+		die(exitInput, fmt.Errorf("Invalid manifest %s: %w", manifestPath, err))
+	}
+	if len(manifest.Chapters) == 0 {
+		die(exitInput, errors.New("The manifest lists no chapter."))
+	}
+	dir := filepath.Dir(manifestPath)
+	if !isFlagPassed("o") {
+		*out = CurrentDir + "/output.htm"
+		if manifest.Output != "" {
+			*out = filepath.Join(dir, manifest.Output)
+		}
+	}
+	fmt.Fprintln(os.Stderr, "In:", manifestPath)
+	fmt.Fprintln(os.Stderr, "Out:", *out)
+	var Chapters []Chapter
+	for _, ch := range manifest.Chapters {
+		ChapterOpts, err := ch.Options.apply(opts, dir)
+		if err != nil {
+			die(exitInput, fmt.Errorf("%s: %w", ch.File, err))
+		}
+		src, err := os.ReadFile(filepath.Join(dir, ch.File))
+		if err != nil {
+			die(exitInput, fmt.Errorf("Could not read chapter %q: %w", ch.Title, err))
+		}
+		doc, err := Process(string(src), ChapterOpts)
+		if errors.Is(err, ErrInvalidRe) {
+			die(exitRegexp, fmt.Errorf("%s: %w", ch.File, err))
+		} else if err != nil {
+			die(exitFailure, fmt.Errorf("%s: %w", ch.File, err))
+		}
+		if doc.Ellipses > 0 {
+			fmt.Fprintf(os.Stderr, "%s%s: %d occurence(s) of '...' or '…', "+
+				"the chanting text could be incomplete%s\n", Orange, ch.File, doc.Ellipses, ANSIReset)
+		}
+		if ch.Title == "" {
+			ch.Title = title(ch.File)
+		}
+		Chapters = append(Chapters, Chapter{Title: ch.Title, Doc: doc})
+	}
+	r := newRenderer(manifestPath, "htm").(HTMLRenderer)
+	r.Title = manifest.Title
+	var buf bytes.Buffer
+	if err = r.RenderBook(&buf, Chapters); err != nil {
+		die(exitFailure, err)
+	}
+	if err = os.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		die(exitWrite, fmt.Errorf("Could not write the output: %w", err))
+	}
+	fmt.Fprintln(os.Stderr, "Done")
+}
+
+func (o ChapterOptionsType) apply(opts Options, dir string) (Options, error) {
+	if o.Hint != nil {
+		opts.Hint = *o.Hint
+	}
+	if o.Comments != nil {
+		opts.CmtMarks = *o.Comments
+	}
+	if o.Re != nil {
+		opts.Re = *o.Re
+	}
+	if o.Thai != nil {
+		opts.ThaiTranslit = *o.Thai
+	}
+	if o.Script != nil {
+		script, err := ParseScript(*o.Script)
+		if err != nil {
+			return opts, err
+		}
+		opts.Script = script
+	}
+	if o.Scheme != nil {
+		scheme, err := ParseScheme(*o.Scheme)
+		if err != nil {
+			return opts, err
+		}
+		opts.Scheme = scheme
+	}
+	if o.OptionalLow != nil {
+		opts.OptionalLow = *o.OptionalLow
+	}
+	if o.HintConfig != nil {
+		cfg, err := ParseHintConfig(*o.HintConfig)
+		if err != nil {
+			return opts, err
+		}
+		opts.HintConfig = &cfg
+	}
+	if o.Pe != nil {
+		opts.ExpandPe = *o.Pe
+	}
+	if o.Formulas != "" {
+		f, err := os.Open(filepath.Join(dir, o.Formulas))
+		if err != nil {
+			return opts, err
+		}
+		defer f.Close()
+		if opts.Formulas, err = ParseFormulas(f); err != nil {
+			return opts, err
+		}
+	}
+	if o.Exceptions != "" {
+		f, err := os.Open(filepath.Join(dir, o.Exceptions))
+		if err != nil {
+			return opts, err
+		}
+		defer f.Close()
+		ex, err := ParseExceptions(f)
+		if err != nil {
+			return opts, err
+		}
+		opts.Exceptions = opts.Exceptions.Merge(ex)
+	}
+	return opts, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConfigType holds the options of a configuration file, keyed by flag name, e.g.
+//
+//	{
+//	  "f": 28,
+//	  "c": "[:]",
+//	  "profiles": {
+//	    "phone": {"l": 2, "f": 24}
+//	  }
+//	}
+//
+// Relative paths are resolved against the directory of the file.
+type ConfigType struct {
+	Path     string
+	Options  map[string]any
+	Profiles map[string]map[string]any
+}
+
+var (
+	ProjectConfigName = "giita.json"
+	BuiltinProfiles   = map[string]map[string]any{
+		"phone":       {"l": 2, "f": 28},
+		"print":       {"f": 18, "d": false, "train": false},
+		"samyok-dark": {"samyok": true, "d": true},
+	}
+	// options holding a path
+	PathOptions = map[string]bool{"i": true, "o": true, "css": true, "x": true, "formulas": true, "lexicon": true}
+)
+
+// applyConfig resolves the configuration from, in order of precedence: the flags
+// passed on the command line, the profiles selected with -profile, the project
+// configuration (giita.json in the working directory, or the file given with
+// -config) and the user configuration.
+func applyConfig() error {
+	cli := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		cli[f.Name] = true
+	})
+	var configs []ConfigType
+	if dir, err := os.UserConfigDir(); err == nil {
+		cfg, err := loadConfig(filepath.Join(dir, "giita", "config.json"), true)
+		if err != nil {
+			return err
+		}
+		configs = append(configs, cfg)
+	}
+	projectPath, optional := ProjectConfigName, true
+	if *configPath != "" {
+		projectPath, optional = *configPath, false
+	}
+	cfg, err := loadConfig(projectPath, optional)
+	if err != nil {
+		return err
+	}
+	configs = append(configs, cfg)
+	set := func(options map[string]any, origin, dir string) error {
+		for name, value := range options {
+			if cli[name] {
+				continue
+			}
+			if flag.Lookup(name) == nil || name == "config" || name == "profile" {
+				return fmt.Errorf("%s: unknown option %q", origin, name)
+			}
+			if err := flag.Set(name, configValue(name, value, dir)); err != nil {
+				return fmt.Errorf("%s: invalid value for %q: %w", origin, name, err)
+			}
+		}
+		return nil
+	}
+	for _, cfg := range configs {
+		if err := set(cfg.Options, cfg.Path, filepath.Dir(cfg.Path)); err != nil {
+			return err
+		}
+	}
+	if *profile == "" {
+		return nil
+	}
+	for _, name := range strings.Split(*profile, ",") {
+		options, ok := BuiltinProfiles[name]
+		origin, dir := "profile "+name, ""
+		for _, cfg := range configs {
+			if p, found := cfg.Profiles[name]; found {
+				options, ok = p, true
+				origin, dir = cfg.Path+": "+origin, filepath.Dir(cfg.Path)
+			}
+		}
+		if !ok {
+			return fmt.Errorf("unknown profile %q", name)
+		}
+		if err := set(options, origin, dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configValue formats a JSON value as the value of the flag name
+func configValue(name string, value any, dir string) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		if PathOptions[name] && v != "" && v != "-" && !filepath.IsAbs(v) {
+			return filepath.Join(dir, v)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+func loadConfig(path string, optional bool) (cfg ConfigType, err error) {
+	cfg.Path = path
+	dat, err := os.ReadFile(path)
+	if optional && errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	} else if err != nil {
+		return cfg, fmt.Errorf("Could not read the configuration file: %w", err)
+	}
+	var raw struct {
+		Profiles map[string]map[string]any `json:"profiles"`
+	}
+	if err = json.Unmarshal(dat, &cfg.Options); err == nil {
+		err = json.Unmarshal(dat, &raw)
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("Invalid configuration file %s: %w", path, err)
+	}
+	delete(cfg.Options, "profiles")
+	cfg.Profiles = raw.Profiles
+	return
+}
+
+// effectiveConfig returns the resolved configuration as the equivalent command line.
+func effectiveConfig() string {
+	var args []string
+	flag.Visit(func(f *flag.Flag) {
+		value := f.Value.String()
+		if strings.ContainsAny(value, " \t\n\"") {
+			value = fmt.Sprintf("%q", value)
+		}
+		args = append(args, "-"+f.Name+"="+value)
+	})
+	return strings.Join(append([]string{"giita"}, args...), " ")
+}
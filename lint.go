@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	. "github.com/tassa-yoniso-manasi-karoto/giita/pkg/libgiita"
+)
+
+// lint prints the diagnostics of each file as "file:line:col: severity: message"
+// and returns exitLint if any error was found. "-" designates stdin.
+func lint(files []string, opts Options) (code int) {
+	var errs, warnings int
+	for _, file := range files {
+		var (
+			dat []byte
+			err error
+		)
+		name := file
+		if file == "-" {
+			name = "<stdin>"
+			dat, err = io.ReadAll(os.Stdin)
+		} else {
+			dat, err = os.ReadFile(file)
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			err = errors.New("Input file does not exist.")
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			code = exitInput
+			continue
+		}
+		diags, err := Lint(string(dat), opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			code = exitFailure
+			if errors.Is(err, ErrInvalidRe) {
+				code = exitRegexp
+			}
+			continue
+		}
+		for _, d := range diags {
+			fmt.Printf("%s:%s\n", name, d)
+			if d.Severity == Error {
+				errs += 1
+			} else {
+				warnings += 1
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d error(s), %d warning(s)\n", errs, warnings)
+	if code == exitOK && errs > 0 {
+		code = exitLint
+	}
+	return
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain runs giita itself when the tests re-execute their binary
+func TestMain(m *testing.M) {
+	if os.Getenv("GIITA_TEST_MAIN") == "1" {
+		os.Args = append([]string{"giita"}, strings.Fields(os.Getenv("GIITA_TEST_ARGS"))...)
+		main()
+		os.Exit(exitOK)
+	}
+	os.Exit(m.Run())
+}
+
+// run runs giita with args in dir, away from the user's configuration, and
+// returns its exit code
+func run(t *testing.T, dir string, args ...string) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0])
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIITA_TEST_MAIN=1", "GIITA_TEST_ARGS="+strings.Join(args, " "),
+		"HOME="+dir, "XDG_CONFIG_HOME="+dir, "AppData="+dir)
+	output, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		t.Logf("giita %s:\n%s", strings.Join(args, " "), output)
+		return exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return exitOK
+}
+
+func TestExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.txt", "b.txt")
+	files := map[string]string{
+		"book.json":  `{"chapters": [{"file": "a.txt"}, {"file": "b.txt"}]}`,
+		"empty.json": `{"chapters": []}`,
+		"lint.txt":   "namo x tassa",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		args string
+		want int
+	}{
+		{"-version", exitOK},
+		{"-t -i a.txt -o a.out", exitOK},
+		{"-t -i missing.txt -o a.out", exitInput},
+		{"-t -re ( -i a.txt -o a.out", exitRegexp},
+		{"-t -o out a.txt b.txt", exitOK},
+		{"-t -o out a.txt missing.txt", exitInput},
+		{"-o book.htm book book.json", exitOK},
+		{"book missing.json", exitInput},
+		{"book empty.json", exitInput},
+		{"-t book book.json", exitUsage},
+		{"lint a.txt", exitOK},
+		{"lint lint.txt", exitLint},
+		{"serve extra", exitUsage},
+		{"-addr nowhere serve", exitFailure},
+		{"-watch -i - -o a.out", exitUsage},
+		{"-watch -i a.txt -o -", exitUsage},
+	}
+	for _, test := range tests {
+		if got := run(t, dir, strings.Fields(test.args)...); got != test.want {
+			t.Errorf("giita %s: exit code %d, want %d", test.args, got, test.want)
+		}
+	}
+}
+
+// config returns the resolved configuration recorded in the HTML output
+func config(t *testing.T, path string) string {
+	t.Helper()
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, after, _ := strings.Cut(string(dat), "\ngiita ")
+	cmt, _, _ := strings.Cut(after, "-->")
+	return cmt
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.txt", "sub/style.css")
+	files := map[string]string{
+		"giita/config.json": `{"addr": "user:1", "gloss": "user", "f": 1, "l": 1, "j": 1000000}`,
+		"giita.json":        `{"gloss": "project", "f": 2, "l": 2, "profiles": {"p": {"f": 3, "l": 3}}}`,
+		"sub/giita.json":    `{"css": "style.css"}`,
+		"bad/giita.json":    `{"f": "big"}`,
+	}
+	for name, content := range files {
+		writeFiles(t, dir, name)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if code := run(t, dir, "-profile", "p", "-l", "4", "-i", "a.txt", "-o", "a.htm"); code != exitOK {
+		t.Fatalf("exit code %d", code)
+	}
+	got := config(t, filepath.Join(dir, "a.htm"))
+	for _, want := range []string{"-addr=user:1", "-gloss=project", "-f=3", "-l=4", "-j=1000000"} {
+		if !strings.Contains(got, want+" ") {
+			t.Errorf("configuration %q, want %s", got, want)
+		}
+	}
+
+	// paths are relative to the configuration file
+	if code := run(t, dir, "-config", "sub/giita.json", "-i", "a.txt", "-o", "b.htm"); code != exitOK {
+		t.Fatalf("exit code %d", code)
+	}
+	if got, want := config(t, filepath.Join(dir, "b.htm")), "-css="+filepath.Join("sub", "style.css")+" "; !strings.Contains(got, want) {
+		t.Errorf("configuration %q, want %s", got, want)
+	}
+
+	// -version works whatever the configuration
+	if code := run(t, dir, "-config", "bad/giita.json", "-version"); code != exitOK {
+		t.Errorf("giita -version: exit code %d", code)
+	}
+	if code := run(t, dir, "-config", "bad/giita.json"); code != exitUsage {
+		t.Errorf("invalid configuration: exit code %d, want %d", code, exitUsage)
+	}
+}
@@ -0,0 +1,311 @@
+package libgiita
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CompoundSeparator is written between the members of compound words by the
+// renderers whose Split option is set.
+const CompoundSeparator = "-"
+
+// DefaultLexiconSrc lists frequent stems of the chanted texts, to find the
+// members of the compound words.
+var DefaultLexiconSrc = `
+# the Triple Gem
+buddha dhamma saṅgha sammā samma sambuddha tathāgata sugata
+vijjā caraṇa sampanna loka vidū anuttara purisa damma sārathi satthā
+deva manussa svākkhāta sandiṭṭhika akālika ehipassika opanayika paccatta
+veditabba viññū paṭipanna uju ñāya sāmīci sāvaka yugala puggala āhuneyya
+pāhuneyya dakkhiṇeyya añjali karaṇīya puñña khetta anussati ratana
+bhikkhu bhikkhunī upāsaka upāsikā brahma cariya vandanā pūjā gāthā
+mahā maṅgala jaya sutta paritta
+
+# teachings
+kāya vacī mano citta cetasika rūpa vedanā saññā saṅkhāra viññāṇa khandha
+āyatana dhātu indriya cakkhu sota ghāna jivhā phoṭṭhabba sadda gandha rasa
+taṇhā upādāna bhava jāti jarā maraṇa soka parideva dukkha domanassa
+upāyāsa avijjā nāma phassa samudaya nirodha magga gāminī paṭipadā ariya
+sacca aṭṭha aṅgika diṭṭhi saṅkappa vācā kammanta ājīva vāyāma sati
+samādhi pīti passaddhi upekkhā sambojjhaṅga paṭṭhāna padhāna iddhi pāda
+bala vimutti vimokkha visuddhi ñāṇa dassana bhāvanā sampayutta vippayutta
+uppāda nibbatta kusala akusala kamma samuṭṭhāna asammūḷha gata pathavī
+āpo tejo vāyo ākāsa ajjhattika bāhira sīla paññā mettā karuṇā muditā ceto
+nibbāna saṃsāra vaṭṭa cakka pavattana anatta anicca sukha kāma rāga dosa
+moha lobha vitakka vicāra ekaggatā jhāna paṭhama dutiya tatiya catuttha
+vipassanā samatha pāramī dāna nekkhamma viriya khanti adhiṭṭhāna uttara
+lokiya pariyatti paṭipatti paṭivedha nīvaraṇa saṃyojana anusaya āsava
+kilesa hetu paccaya paṭicca samuppāda phala sotāpatti āpanna catu pañca
+dasa sabba satta
+`
+
+// Lexicon is a set of stems used to split compound words into their members,
+// see Split. In a lexicon file the stems are separated by spaces or lines,
+// "#" starts a comment.
+type Lexicon map[string]bool
+
+var DefaultLexicon Lexicon
+
+func init() {
+	var err error
+	if DefaultLexicon, err = ParseLexicon(strings.NewReader(DefaultLexiconSrc)); err != nil {
+		panic(err)
+	}
+}
+
+// ParseLexicon reads a lexicon file, see Lexicon for the format.
+func ParseLexicon(r io.Reader) (lex Lexicon, err error) {
+	lex = make(Lexicon)
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n += 1
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		for _, stem := range strings.Fields(line) {
+			stem = strings.ReplaceAll(strings.ToLower(stem), "ṃ", "ṁ")
+			if utf8.RuneCountInString(stem) < minMemberLen || strings.IndexFunc(stem, notLetter) != -1 {
+				return nil, fmt.Errorf("line %d: invalid stem %q", n, stem)
+			}
+			lex[stem] = true
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Merge returns the stems of both lexicons.
+func (lex Lexicon) Merge(other Lexicon) Lexicon {
+	merged := make(Lexicon, len(lex)+len(other))
+	for stem := range lex {
+		merged[stem] = true
+	}
+	for stem := range other {
+		merged[stem] = true
+	}
+	return merged
+}
+
+func notLetter(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.Is(unicode.Mn, r)
+}
+
+const (
+	// in runes, shorter members would give many false splits
+	minMemberLen = 2
+	// in bytes
+	maxMemberLen = 64
+)
+
+var (
+	// long vowels at the junction of two members, and the short vowel they can replace
+	lengthened = map[string]string{"ā": "a", "ī": "i", "ū": "u"}
+	// vowels at the beginning of a member after the elision of the final "a" of
+	// the previous one, and the vowel the member begins with
+	merged = map[string]string{"ā": "a", "ī": "i", "ū": "u", "e": "i", "o": "u"}
+	// vowels at the end of the stems, removed before a vowel or an ending
+	stemVwls = []string{"a", "i", "u", "ā", "ī", "ū"}
+	// endings of the last member, those beginning with a vowel replace the
+	// final vowel of the stem, the others are added to it
+	endings = []string{
+		"aṁ", "ena", "āya", "assa", "asmā", "amhā", "ato", "asmiṁ", "amhi", "e", "o",
+		"ehi", "ebhi", "ānaṁ", "esu", "āni", "āyo", "āhi", "āsu", "āyaṁ", "a", "ā",
+		"iṁ", "inā", "issa", "ismiṁ", "imhi", "ayo", "īhi", "īnaṁ", "īsu", "i", "ī",
+		"uṁ", "unā", "ussa", "usmiṁ", "umhi", "avo", "ūhi", "ūnaṁ", "ūsu", "u", "ū",
+		"ṁ", "ssa", "smiṁ", "mhi", "smā", "mhā", "nā", "hi", "naṁ", "su", "ni", "yo", "yā", "yaṁ", "to",
+	}
+)
+
+// Split returns the byte offsets in word at which a member of the compound
+// ends, the end of the word excepted. The members are stems of the lexicon,
+// allowing for the usual sandhi: elision of the final vowel before a vowel,
+// lengthening of the vowels at the junction, doubling of the first consonant,
+// and for an ending on the last member. nil is returned if the word is made
+// of a single member or can't be entirely split.
+func (lex Lexicon) Split(word string) []int {
+	w := strings.ToLower(word)
+	if len(w) != len(word) || len(lex) == 0 {
+		return nil
+	}
+	type state struct {
+		ok         bool
+		cost, prev int
+		prevElided bool
+	}
+	// states[i][1] if the member ending at i lost its final vowel
+	states := make([][2]state, len(w)+1)
+	states[0][0] = state{ok: true}
+	for i := 0; i < len(w); {
+		for e, st := range states[i] {
+			if !st.ok {
+				continue
+			}
+			for j := i; j < len(w) && j-i <= maxMemberLen; {
+				_, size := utf8.DecodeRuneInString(w[j:])
+				j += size
+				for elided, penalty := range lex.member(w, i, j, e == 1) {
+					if penalty < 0 {
+						continue
+					}
+					// fewer members first, then fewer alterations
+					cost := st.cost + 4 + penalty
+					next := &states[j][elided]
+					if !next.ok || cost < next.cost {
+						*next = state{true, cost, i, e == 1}
+					}
+				}
+			}
+		}
+		_, size := utf8.DecodeRuneInString(w[i:])
+		i += size
+	}
+	end := states[len(w)][0]
+	if !end.ok {
+		return nil
+	}
+	var bounds []int
+	for st := end; st.prev > 0; st = states[st.prev][b2i(st.prevElided)] {
+		bounds = append([]int{st.prev}, bounds...)
+	}
+	return bounds
+}
+
+// member returns the smallest penalty of w[i:j] as a member of a compound,
+// at index 1 if its final vowel was elided, 0 otherwise. The penalty is -1
+// if w[i:j] can't be a member.
+func (lex Lexicon) member(w string, i, j int, afterElision bool) [2]int {
+	res := [2]int{-1, -1}
+	add := func(elided, penalty int) {
+		if res[elided] < 0 || penalty < res[elided] {
+			res[elided] = penalty
+		}
+	}
+	last := j == len(w)
+	for _, v := range lex.starts(w, i, j, afterElision) {
+		s, penalty := v.s, v.penalty
+		if utf8.RuneCountInString(s) < minMemberLen {
+			continue
+		}
+		if lex[s] {
+			add(0, penalty)
+		}
+		if last {
+			for _, ending := range endings {
+				base, found := strings.CutSuffix(s, ending)
+				if !found || base == "" {
+					continue
+				}
+				if lex[base] && !startsWithVowel(ending) {
+					add(0, penalty+1)
+				}
+				for _, vwl := range stemVwls {
+					if startsWithVowel(ending) && lex[base+vwl] {
+						add(0, penalty+1)
+					}
+				}
+			}
+			continue
+		}
+		// lengthened final vowel e.g. "kāyagatāsati"
+		for long, short := range lengthened {
+			if base, found := strings.CutSuffix(s, long); found && lex[base+short] {
+				add(0, penalty+1)
+			}
+		}
+		if startsWithVowel(w[j:]) {
+			for _, vwl := range stemVwls {
+				if lex[s+vwl] {
+					add(1, penalty+1)
+				}
+			}
+		}
+	}
+	return res
+}
+
+type memberStart struct {
+	s       string
+	penalty int
+}
+
+// starts returns w[i:j] along with its forms without the sandhi at its beginning
+func (lex Lexicon) starts(w string, i, j int, afterElision bool) []memberStart {
+	s := w[i:j]
+	starts := []memberStart{{s, 0}}
+	if i == 0 {
+		return starts
+	}
+	// doubled consonant e.g. "dhammacakkappavattana", "ariyacchanda"
+	c, size := utf8.DecodeRuneInString(s)
+	if next, _ := utf8.DecodeRuneInString(s[size:]); c == next && !isVowelRune(c) ||
+		c == 'c' && strings.HasPrefix(s[size:], "ch") {
+		starts = append(starts, memberStart{s[size:], 1})
+	}
+	if afterElision {
+		for vwl, initial := range merged {
+			if rest, found := strings.CutPrefix(s, vwl); found {
+				starts = append(starts, memberStart{initial + rest, 1})
+			}
+		}
+	}
+	return starts
+}
+
+func startsWithVowel(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return isVowelRune(r)
+}
+
+func isVowelRune(r rune) bool {
+	return strings.ContainsRune("aāiīuūeo", r)
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// markMembers sets MemberEnd on the last unit of the syllable in which each
+// member of the compound words ends. A member may end inside a syllable, e.g.
+// "cit|tup|pā" for "citta|uppāda" or "cak|kap|pa" for "cakka|ppavattana": the
+// boundary is then moved to the end of that syllable.
+func (lex Lexicon) markMembers(Syllables []SyllableType) {
+	type owner struct{ s, u int }
+	var word strings.Builder
+	// units of the word, keyed by their end offset in word
+	var owners map[int]owner
+	flush := func() {
+		for _, b := range lex.Split(word.String()) {
+			if o, ok := owners[b]; ok {
+				Units := Syllables[o.s].Units
+				Units[len(Units)-1].MemberEnd = true
+			}
+		}
+		word.Reset()
+		owners = make(map[int]owner)
+	}
+	flush()
+	for s, Syllable := range Syllables {
+		for u, unit := range Syllable.Units {
+			if !unit.isLetter() {
+				flush()
+				continue
+			}
+			word.WriteString(strings.ToLower(unit.Str))
+			owners[word.Len()] = owner{s, u}
+		}
+	}
+	flush()
+}
+
+// endsMember reports whether a member of a compound word ends with the syllable
+func (Syllable *SyllableType) endsMember() bool {
+	return len(Syllable.Units) > 0 && Syllable.Units[len(Syllable.Units)-1].MemberEnd
+}
@@ -0,0 +1,113 @@
+package libgiita
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		word, want string
+	}{
+		{"cakkhuviññāṇaṁ", "cakkhu|viññāṇaṁ"},
+		{"Brahmacariyaṁ", "Brahma|cariyaṁ"},
+		// lengthened final vowel
+		{"kāyagatāsati", "kāya|gatā|sati"},
+		// elision and lengthened initial vowel
+		{"cittuppāda", "citt|uppāda"},
+		{"buddhānussati", "buddh|ānussati"},
+		{"lokuttara", "lok|uttara"},
+		// doubled consonant
+		{"dhammacakkappavattana", "dhamma|cakka|ppavattana"},
+		{"puññakkhettaṁ", "puñña|kkhettaṁ"},
+		// endings
+		{"sammāsambuddhassa", "sammā|sambuddhassa"},
+		{"ariyasaccāni", "ariya|saccāni"},
+		// single member or unknown stems
+		{"dhammaṁ", "dhammaṁ"},
+		{"bhagavato", "bhagavato"},
+		{"dhammasaraṇaṁ", "dhammasaraṇaṁ"},
+	}
+	for _, test := range tests {
+		got, last := "", 0
+		for _, b := range DefaultLexicon.Split(test.word) {
+			got += test.word[last:b] + "|"
+			last = b
+		}
+		if got += test.word[last:]; got != test.want {
+			t.Errorf("Split(%q) = %q, want %q", test.word, got, test.want)
+		}
+	}
+}
+
+func TestParseLexicon(t *testing.T) {
+	lex, err := ParseLexicon(strings.NewReader("# refuge\nsaraṇa  gamana\nSaṃsāra"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lex) != 3 || !lex["saraṇa"] || !lex["gamana"] || !lex["saṁsāra"] {
+		t.Errorf("ParseLexicon = %v", lex)
+	}
+	if b := DefaultLexicon.Merge(lex).Split("saraṇagamanaṁ"); len(b) != 1 || b[0] != len("saraṇa") {
+		t.Errorf("Split with merged lexicon = %v", b)
+	}
+	for _, src := range []string{"a", "dham-ma", "buddha 3"} {
+		if _, err := ParseLexicon(strings.NewReader(src)); err == nil {
+			t.Errorf("ParseLexicon(%q): no error", src)
+		}
+	}
+}
+
+func TestCompounds(t *testing.T) {
+	src := "Dukkhanirodhagāminīpaṭipadāariyasaccapaṭivedhañāṇadassanavisuddhivimuttisukhasampannā."
+	hints := func(opts Options) (s []string) {
+		doc, err := Process(src, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, Syllable := range doc.Paragraphs[0][0] {
+			if Syllable.Hint {
+				s = append(s, Syllable.String())
+			}
+		}
+		return
+	}
+	// the first hint moves to the end of "paṭipadā"
+	if got := hints(Options{Hint: 4.5}); len(got) == 0 || got[0] != "sac" {
+		t.Errorf("hints without lexicon = %q", got)
+	}
+	if got := hints(Options{Hint: 4.5, Lexicon: DefaultLexicon}); len(got) == 0 || got[0] != "dā" {
+		t.Errorf("hints with lexicon = %q", got)
+	}
+
+	doc, err := Process("cittuppādo, dhammacakkaṁ buddhānussati dhammacakkappavattanaṁ", Options{Lexicon: DefaultLexicon})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err = (TextRenderer{Newlines: 1, Split: true}).RenderDocument(&b, doc); err != nil {
+		t.Fatal(err)
+	}
+	// the members end with a syllable, even across a sandhi or a geminate
+	if want := "cit⸱tup-pā⸱do,█ dham⸱ma-cak⸱kaṁ bud⸱dhā-nus⸱sa⸱ti dham⸱ma-cak⸱kap-pa⸱vat⸱ta⸱naṁ"; b.String() != want {
+		t.Errorf("split text = %q, want %q", b.String(), want)
+	}
+	b.Reset()
+	if err = (HTMLRenderer{Newlines: 1, Split: true}).RenderDocument(&b, doc); err != nil {
+		t.Fatal(err)
+	}
+	if want := `ma</span><span class=cj></span><span class="long">cak`; !strings.Contains(b.String(), want) {
+		t.Errorf("%s not found in the html output", want)
+	}
+	b.Reset()
+	if err = (JSONRenderer{}).RenderDocument(&b, doc); err != nil {
+		t.Fatal(err)
+	}
+	back, err := DecodeJSON(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Syllable := back.Paragraphs[0][1][1]; !Syllable.Units[len(Syllable.Units)-1].MemberEnd {
+		t.Errorf("member end lost in JSON: %+v", back.Paragraphs[0][1][1])
+	}
+}
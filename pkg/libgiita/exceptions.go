@@ -0,0 +1,270 @@
+package libgiita
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	forceNone = iota
+	forceClose
+	forceOpen
+)
+
+// DefaultExceptionsSrc lists the words known to be wrongly syllabified or toned by the rules.
+var DefaultExceptionsSrc = `
+# "nh" begins a syllable even in the middle of a word
+*nhā*   = nhā
+# the Thai script writes the "m" following "h" as หม, a consonant of the high
+# class: the short syllable gets the optional low tone
+*brahm* = brah|,m
+*brāhm* = brāh|,m
+`
+
+// Exception overrides the syllabification and/or the tones of the words matching its pattern.
+//
+// In an exceptions file, each line holds a pattern and its split separated by
+// "=", "#" starts a comment. A leading "*" in the pattern matches any beginning
+// of word, a trailing "*" any end of word, e.g.
+//
+//	brāhmaṇa  = brāh|ma|ṇa
+//	*brahm*   = brah|m
+//	bhagavā   = ^bha|ga|_vā
+//
+// "|" separates the syllables. A syllable boundary is also set at the beginning
+// of the matched part of a word, the end of the matched part is left to the rules
+// unless the split ends with "|". Syllables can be prefixed with a tone marker:
+// "^" for a true high tone, "?" for an optional high tone, "," for an optional low
+// tone (only if enabled by Options.OptionalLow, the tones of the rules are kept
+// otherwise), "_" for the mid tone.
+type Exception struct {
+	Pattern   string
+	Line      int
+	word      string
+	anyBefore bool
+	anyAfter  bool
+	chunks    []string
+	tones     []byte
+	closeEnd  bool
+}
+
+type Exceptions []Exception
+
+var DefaultExceptions Exceptions
+
+func init() {
+	var err error
+	if DefaultExceptions, err = ParseExceptions(strings.NewReader(DefaultExceptionsSrc)); err != nil {
+		panic(err)
+	}
+}
+
+// ParseExceptions reads an exceptions file, see Exception for the format.
+func ParseExceptions(r io.Reader) (ex Exceptions, err error) {
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n += 1
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		pattern, split, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: missing \"=\" between pattern and split", n)
+		}
+		e, err := newException(strings.TrimSpace(pattern), strings.TrimSpace(split))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		e.Line = n
+		ex = append(ex, e)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	ex.sort()
+	return
+}
+
+func newException(pattern, split string) (e Exception, err error) {
+	e.Pattern = pattern
+	e.word = strings.ToLower(pattern)
+	e.word, e.anyBefore = strings.CutPrefix(e.word, "*")
+	e.word, e.anyAfter = strings.CutSuffix(e.word, "*")
+	if e.word == "" {
+		return e, fmt.Errorf("empty pattern")
+	}
+	split, e.closeEnd = strings.CutSuffix(strings.ToLower(split), "|")
+	for _, chunk := range strings.Split(split, "|") {
+		tone := byte(0)
+		if chunk != "" && strings.ContainsAny(chunk[:1], "^?,_") {
+			tone, chunk = chunk[0], chunk[1:]
+		}
+		if chunk == "" {
+			return e, fmt.Errorf("empty syllable in %q", split)
+		}
+		e.chunks = append(e.chunks, chunk)
+		e.tones = append(e.tones, tone)
+	}
+	if joined := strings.Join(e.chunks, ""); joined != e.word {
+		return e, fmt.Errorf("split %q doesn't match pattern %q", joined, e.word)
+	}
+	if _, ok := e.boundaries(Parser(e.word)); !ok {
+		return e, fmt.Errorf("split %q cuts through a letter", split)
+	}
+	return
+}
+
+// Merge returns the exceptions of both lists, those of other taking precedence
+// over those of ex with the same pattern.
+func (ex Exceptions) Merge(other Exceptions) (merged Exceptions) {
+	seen := make(map[string]bool)
+	for _, e := range other {
+		seen[e.Pattern] = true
+		merged = append(merged, e)
+	}
+	for _, e := range ex {
+		if !seen[e.Pattern] {
+			merged = append(merged, e)
+		}
+	}
+	merged.sort()
+	return
+}
+
+// exact matches first, then the longest patterns
+func (ex Exceptions) sort() {
+	sort.SliceStable(ex, func(i, j int) bool {
+		exactI, exactJ := !ex[i].anyBefore && !ex[i].anyAfter, !ex[j].anyBefore && !ex[j].anyAfter
+		if exactI != exactJ {
+			return exactI
+		}
+		return len(ex[i].word) > len(ex[j].word)
+	})
+}
+
+// boundaries returns the indexes of the units starting each chunk of the
+// exception, units being those of the matched part only.
+func (e *Exception) boundaries(Units []UnitType) (idx []int, ok bool) {
+	i := 0
+	consume := func(length int) bool {
+		for ; i < len(Units) && length > 0; i++ {
+			length -= len(strings.ToLower(Units[i].Str))
+		}
+		return length == 0
+	}
+	for k, chunk := range e.chunks {
+		if k > 0 && !consume(len(e.chunks[k-1])) {
+			return nil, false
+		}
+		idx = append(idx, i)
+		if k == len(e.chunks)-1 && (!consume(len(chunk)) || i != len(Units)) {
+			return nil, false
+		}
+	}
+	return idx, true
+}
+
+// match returns the byte offset of the pattern in the lowercase word, or -1.
+func (e *Exception) match(word string) int {
+	switch {
+	case e.anyBefore && e.anyAfter:
+		return strings.Index(word, e.word)
+	case e.anyBefore && strings.HasSuffix(word, e.word):
+		return len(word) - len(e.word)
+	case e.anyAfter && strings.HasPrefix(word, e.word):
+		return 0
+	case word == e.word:
+		return 0
+	}
+	return -1
+}
+
+// Apply marks the units of the words matching an exception so that
+// SyllableBuilder and SetTones follow the exception rather than the rules.
+// Only the first matching exception, in order of precedence, is applied to a word.
+func (ex Exceptions) Apply(Units []UnitType) {
+	if len(ex) == 0 {
+		return
+	}
+	for start := 0; start < len(Units); {
+		end := start
+		for end < len(Units) && Units[end].IsRelevant() {
+			end += 1
+		}
+		if end == start {
+			start += 1
+			continue
+		}
+		ex.applyWord(Units, start, end)
+		start = end
+	}
+}
+
+func (ex Exceptions) applyWord(Units []UnitType, start, end int) {
+	var word string
+	offsets := make(map[int]int)
+	for i := start; i < end; i++ {
+		offsets[len(word)] = i
+		word += strings.ToLower(Units[i].Str)
+	}
+	offsets[len(word)] = end
+	for _, e := range ex {
+		pos := e.match(word)
+		if pos < 0 {
+			continue
+		}
+		first, ok := offsets[pos]
+		last, ok2 := offsets[pos+len(e.word)]
+		if !ok || !ok2 {
+			continue
+		}
+		idx, ok := e.boundaries(Units[first:last])
+		if !ok {
+			continue
+		}
+		if first > start {
+			Units[first-1].force = forceClose
+		}
+		for k, i := range idx {
+			chunkEnd := last
+			if k+1 < len(idx) {
+				chunkEnd = first + idx[k+1]
+			}
+			for j := first + i; j < chunkEnd-1; j++ {
+				Units[j].force = forceOpen
+			}
+			if k+1 < len(idx) || e.closeEnd {
+				Units[chunkEnd-1].force = forceClose
+			}
+			Units[first+i].tone = e.tones[k]
+		}
+		return
+	}
+}
+
+// applyTones overrides the tones set by SetTones with those of the exceptions.
+func applyTones(Syllables []SyllableType, wantOptionalLow bool) {
+	for i, Syllable := range Syllables {
+		for _, unit := range Syllable.Units {
+			Syl := &Syllables[i]
+			switch unit.tone {
+			case '^':
+				Syl.TrueHigh, Syl.OptionalHigh, Syl.OptionalLow = true, false, false
+			case '?':
+				Syl.TrueHigh, Syl.OptionalHigh, Syl.OptionalLow = false, true, false
+			case ',':
+				// the tones of the rules are kept if the optional low tone is disabled
+				if wantOptionalLow {
+					Syl.TrueHigh, Syl.OptionalHigh, Syl.OptionalLow = false, false, true
+				}
+			case '_':
+				Syl.TrueHigh, Syl.OptionalHigh, Syl.OptionalLow = false, false, false
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package libgiita
+
+import (
+	"strings"
+	"testing"
+)
+
+// processedTones returns the syllables of src as processed with opts, the optional
+// low tones followed by OptionalLowMark.
+func processedTones(t *testing.T, src string, opts Options) (s string) {
+	t.Helper()
+	doc, err := Process(src, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, Paragraph := range doc.Paragraphs {
+		for _, Segment := range Paragraph {
+			for _, Syllable := range Segment {
+				if Syllable.Irrelevant {
+					continue
+				}
+				s += Syllable.String()
+				if Syllable.OptionalLow {
+					s += OptionalLowMark
+				}
+				s += "|"
+			}
+		}
+	}
+	return
+}
+
+// analysis formats the relevant syllables of the first segment of src: tone
+// (H true high, h optional high, l optional low, - none) and length (L long, S short).
+func analysis(t *testing.T, src string, opts Options) string {
+	t.Helper()
+	doc, err := Process(src, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s []string
+	for _, Syllable := range doc.Paragraphs[0][0] {
+		if !Syllable.Irrelevant {
+			one := []SyllableType{Syllable}
+			s = append(s, Syllable.String()+":"+tones(one)+lengths(one))
+		}
+	}
+	return strings.Join(s, " ")
+}
+
+// TestKnownIssues covers the failures listed in the README, fixed by DefaultExceptions.
+func TestKnownIssues(t *testing.T) {
+	tests := []struct {
+		issue, word, before, after string
+	}{
+		{"brāhma", "brāhmaṇo", "b:-S rāh:-L ma:hS ṇo:-L", "brāh:-L ma:hS ṇo:-L"},
+		{"brāhma", "brahmacariyaṁ", "b:-S rah:hL ma:hS ca:-S ri:hS yaṁ:-L", "brah:-L ma:hS ca:-S ri:hS yaṁ:-L"},
+		{"brāhma", "sabrahmacārī", "sab:-L rah:hL ma:hS cā:-L rī:-L", "sa:-S brah:-L ma:hS cā:-L rī:-L"},
+		{"nhārū", "nhārū", "n:-S hā:HL rū:-L", "nhā:-L rū:-L"},
+		{"nhārū", "aṭṭhinhāru", "aṭ:-L ṭhin:HL hā:HL ru:hS", "aṭ:-L ṭhi:-S nhā:-L ru:hS"},
+		{"nhāyeyya", "nhāyeyya", "n:-S hā:HL yey:-L ya:hS", "nhā:-L yey:-L ya:hS"},
+		{"nhāyeyya", "sinhāyati", "sin:HL hā:HL ya:hS ti:-S", "si:-S nhā:-L ya:hS ti:-S"},
+	}
+	for _, tt := range tests {
+		if got := analysis(t, tt.word, Options{}); got != tt.before {
+			t.Errorf("%s: %q without exceptions = %q, want %q", tt.issue, tt.word, got, tt.before)
+		}
+		if got := analysis(t, tt.word, Options{Exceptions: DefaultExceptions}); got != tt.after {
+			t.Errorf("%s: %q with exceptions = %q, want %q", tt.issue, tt.word, got, tt.after)
+		}
+	}
+}
+
+func TestOptionalLowExceptions(t *testing.T) {
+	tests := []struct {
+		word, rules, exceptions string
+	}{
+		{"brahmaṁ", "b|rah|maṁ|", "brah|maṁ↓|"},
+		{"brāhmaṇo", "b|rāh|ma|ṇo|", "brāh|ma↓|ṇo|"},
+	}
+	for _, tt := range tests {
+		if got := processedTones(t, tt.word, Options{OptionalLow: true}); got != tt.rules {
+			t.Errorf("%q without exceptions = %q, want %q", tt.word, got, tt.rules)
+		}
+		if got := processedTones(t, tt.word, Options{OptionalLow: true, Exceptions: DefaultExceptions}); got != tt.exceptions {
+			t.Errorf("%q with exceptions = %q, want %q", tt.word, got, tt.exceptions)
+		}
+		// no optional low tone if disabled
+		if got := processedTones(t, tt.word, Options{Exceptions: DefaultExceptions}); strings.Contains(got, OptionalLowMark) {
+			t.Errorf("%q without -optionallow = %q, want no optional low tone", tt.word, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package libgiita
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// seeds shared by the fuzz targets, regressions found by fuzzing are stored in
+// testdata/fuzz/<target>
+var fuzzSeeds = []string{
+	"Namo tassa bhagavato arahato sammāsambuddhassa.",
+	"Itipi so bhagavā arahaṃ, viññūhī’ti...\n\nBrāhmaṇa nhārū 123 x.",
+	"svākkhāto brahmā sinhāyati",
+	"a",
+	"’",
+	"m’ b",
+	" \n ",
+	"ṭh",
+	"\xff\xfe",
+	"พุทฺธํ สรณํ คจฺฉามิ",
+}
+
+func FuzzParser(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		var b strings.Builder
+		for i, unit := range Parser(src) {
+			if unit.Str == "" {
+				t.Fatalf("unit %d is empty", i)
+			}
+			b.WriteString(unit.Str)
+		}
+		if b.String() != src {
+			t.Fatalf("units do not reproduce the input:\n got %q\nwant %q", b.String(), src)
+		}
+		if got, want := units(Parser(src)), units(referenceParser(src)); got != want {
+			t.Fatalf("Parser differs from referenceParser:\n got %s\nwant %s", got, want)
+		}
+	})
+}
+
+func FuzzSyllableBuilder(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		Syllables := SetOptionalLow(SetTones(SyllableBuilder(Parser(src))))
+		var b strings.Builder
+		for i, Syllable := range Syllables {
+			if len(Syllable.Units) == 0 {
+				t.Fatalf("syllable %d is empty", i)
+			}
+			b.WriteString(Syllable.String())
+		}
+		if b.String() != src {
+			t.Fatalf("syllables do not reproduce the input:\n got %q\nwant %q", b.String(), src)
+		}
+	})
+}
+
+// FuzzProcess runs the whole pipeline, with the exceptions and all renderers
+func FuzzProcess(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed, 4.5, true)
+	}
+	f.Fuzz(func(t *testing.T, src string, hint float64, optionalLow bool) {
+		opts := Options{Hint: hint, CmtMarks: "[:]", OptionalLow: optionalLow, Exceptions: DefaultExceptions}
+		doc, err := Process(src, opts)
+		if err != nil {
+			return
+		}
+		for _, Paragraph := range doc.Paragraphs {
+			for _, Segment := range Paragraph {
+				for i, Syllable := range Segment {
+					if len(Syllable.Units) == 0 {
+						t.Fatalf("syllable %d of segment %q is empty", i, Segment.String())
+					}
+				}
+			}
+		}
+		for _, r := range []Renderer{HTMLRenderer{Newlines: 1}, TextRenderer{Newlines: 1}, JSONRenderer{}} {
+			if err := r.RenderDocument(io.Discard, doc); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package libgiita
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGlosses(t *testing.T) {
+	src := "= Title\nnamo tassa\n  = Homage to him\n\nbuddhaṃ\ndhammaṃ\n= Buddha\n=Dhamma\nsaṅghaṃ"
+	doc, err := Process(src, Options{Gloss: "="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Title", "Homage to him", "Buddha\nDhamma"}
+	if strings.Join(doc.Glosses, "|") != strings.Join(want, "|") {
+		t.Errorf("glosses = %q, want %q", doc.Glosses, want)
+	}
+	var text string
+	for _, Paragraph := range doc.Paragraphs {
+		for _, Segment := range Paragraph {
+			text += Segment.String()
+		}
+	}
+	if want := "𐂃\nnamo tassa𐂃\n\nbuddhaṁ\ndhammaṁ𐂃\nsaṅghaṁ"; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+
+	var b strings.Builder
+	if err = (HTMLRenderer{Newlines: 1, Interlinear: "side"}).RenderDocument(&b, doc); err != nil {
+		t.Fatal(err)
+	}
+	body := regexp.MustCompile(`<span[^>]*>|</span>|\n`).ReplaceAllString(b.String()[strings.Index(b.String(), "<body>"):], "")
+	rows := []string{
+		`<div class="mainp il side"><div class=row><div class=pali></div><div class=gloss>Title</div></div>`,
+		`<div class=row><div class=pali>namo tassa</div><div class=gloss>Homage to him</div></div></div>`,
+		`<div class=row><div class=pali>buddhaṁ<br>dhammaṁ</div><div class=gloss>Buddha<br>Dhamma</div></div>`,
+		`<div class=row><div class=pali>saṅghaṁ</div><div class=gloss></div></div></div>`,
+	}
+	for _, row := range rows {
+		if !strings.Contains(body, row) {
+			t.Errorf("row %s not found in\n%s", row, body)
+		}
+	}
+
+	b.Reset()
+	if err = (JSONRenderer{}).RenderDocument(&b, doc); err != nil {
+		t.Fatal(err)
+	}
+	back, err := DecodeJSON(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(back.Glosses, "|") != strings.Join(want, "|") {
+		t.Errorf("glosses read back from JSON = %q", back.Glosses)
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/tassa-yoniso-manasi-karoto/giita/pkg/libgiita
 
 go 1.22.5
+
+require github.com/tassa-yoniso-manasi-karoto/pali-transliteration v0.0.0-20231126055423-4a217fb3552a
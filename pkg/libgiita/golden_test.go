@@ -0,0 +1,111 @@
+package libgiita
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// go test -update rewrites the golden files after an intended change of the
+// rules, the diff of testdata/golden then shows its effect on the corpus.
+var update = flag.Bool("update", false, "rewrite the golden files of testdata/golden")
+
+// goldenCombos are the combinations of options checked against each text of
+// testdata/corpus, the name is that of the golden file.
+var goldenCombos = []struct {
+	name string
+	opts func(*Options)
+	r    Renderer
+}{
+	{"default.htm", nil, HTMLRenderer{FontSize: 34, Newlines: 1}},
+	{"default.txt", nil, TextRenderer{Newlines: 1}},
+	{"dark-samyok.htm", nil, HTMLRenderer{FontSize: 34, Newlines: 1, Dark: true, Samyok: true}},
+	{"noto-l2-f20.htm", nil, HTMLRenderer{FontSize: 20, Newlines: 2, Noto: true}},
+	{"train.htm", nil, HTMLRenderer{FontSize: 34, Newlines: 1, Train: true}},
+	{"optionalhigh.txt", nil, TextRenderer{Newlines: 1, OptionalHigh: true}},
+	{"optionallow.htm", func(o *Options) { o.OptionalLow = true }, HTMLRenderer{FontSize: 34, Newlines: 1}},
+	{"optionallow.txt", func(o *Options) { o.OptionalLow = true }, TextRenderer{Newlines: 1}},
+	{"hint0.txt", func(o *Options) { o.Hint = 0 }, TextRenderer{Newlines: 1}},
+	{"hint6.txt", func(o *Options) { o.Hint = 6 }, TextRenderer{Newlines: 1}},
+	{"nocomments.txt", func(o *Options) { o.CmtMarks = "" }, TextRenderer{Newlines: 1}},
+	{"noexceptions.txt", func(o *Options) { o.Exceptions = nil }, TextRenderer{Newlines: 1}},
+	{"analysis.json", nil, JSONRenderer{Indent: "  "}},
+}
+
+func TestGolden(t *testing.T) {
+	corpus, err := filepath.Glob(filepath.Join("testdata", "corpus", "*.txt"))
+	if err != nil || len(corpus) == 0 {
+		t.Fatal("no corpus in testdata/corpus", err)
+	}
+	for _, path := range corpus {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text := strings.TrimSuffix(filepath.Base(path), ".txt")
+		for _, combo := range goldenCombos {
+			t.Run(text+"/"+combo.name, func(t *testing.T) {
+				opts := Options{Hint: 4.5, CmtMarks: "[:]", Exceptions: DefaultExceptions}
+				if combo.opts != nil {
+					combo.opts(&opts)
+				}
+				doc, err := Process(string(src), opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r := combo.r
+				if h, ok := r.(HTMLRenderer); ok {
+					h.Title = text
+					r = h
+				}
+				var buf bytes.Buffer
+				if err = r.RenderDocument(&buf, doc); err != nil {
+					t.Fatal(err)
+				}
+				golden := filepath.Join("testdata", "golden", text, combo.name)
+				if *update {
+					if err = os.MkdirAll(filepath.Dir(golden), 0755); err == nil {
+						err = os.WriteFile(golden, buf.Bytes(), 0644)
+					}
+					if err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("%v (run go test -update to create it)", err)
+				}
+				if diff := lineDiff(string(want), buf.String()); diff != "" {
+					t.Errorf("output differs from %s (run go test -update if intended):\n%s", golden, diff)
+				}
+			})
+		}
+	}
+}
+
+// lineDiff lists the lines that differ between want and got, empty if none
+func lineDiff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	var b strings.Builder
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			fmt.Fprintf(&b, "line %d:\n-%s\n+%s\n", i+1, w, g)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,323 @@
+package libgiita
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	green     = "\033[38;5;2m"
+	ansiReset = "\033[0m"
+)
+
+// MakeHint places hints on the long syllables of a segment where to catch
+// one's breath, according to opts.HintConfig.
+func MakeHint(Segment SegmentType, SegmentProcessed *int, opts Options) SegmentType {
+	w := opts.Debug.out()
+	cfg := opts.hintConfig()
+	report := opts.Debug.Report
+	SubsegmentTotal := 1
+	StatsTotal := Segment.DescribeUpTo(-1)
+	BeatsTotal := StatsTotal.Long*2 + StatsTotal.Short
+	BeatsDone := 0
+	// Target is the number of beats around which we search. Beats = long Syllables increment by 2 and spaces by 0.
+	// TargetIdx is the corresponding position expressed as a regular array index.
+	// MaxSpread is also expressed in array increments, not in beats, and corresponds to the radius, not the diameter.
+	Target := cfg.FirstTarget
+	MaxSpread := cfg.MaxSpread
+	if report {
+		fmt.Fprintf(w, "[report] %q: %d beats\n", Segment.String(), BeatsTotal)
+	}
+	noHint := func() string {
+		if SubsegmentTotal > 1 {
+			return "no further hint"
+		}
+		return "no hint"
+	}
+	for BeatsTotal-BeatsDone > Target+MaxSpread {
+		// +1 because need 1 more slot for the int that is the target (= the starting point)
+		vals := make([]int, MaxSpread*2+1)
+		indexes := make([]int, MaxSpread*2+1)
+		TargetIdx := Segment.FindIdxMatchingBeats(Target + BeatsDone)
+		if report {
+			fmt.Fprintf(w, "  target at %d beats %q, candidates within %d syllables:\n",
+				Target+BeatsDone, Segment[TargetIdx].String(), MaxSpread)
+		}
+		idx := 0
+		for sp := -MaxSpread; sp <= MaxSpread; sp++ {
+			if 0 <= TargetIdx+sp && TargetIdx+sp < len(Segment) {
+				var terms string
+				vals[idx], terms = rate(StatsTotal, Segment, TargetIdx, Target, MaxSpread, sp, cfg, opts)
+				indexes[idx] = TargetIdx + sp
+				idx += 1
+				if report {
+					fmt.Fprintf(w, "    %-10q %6d  %s\n", Segment[TargetIdx+sp].String(), vals[idx-1], terms)
+				}
+			}
+		}
+		Rating := RatingType{sort.IntSlice(vals), indexes}
+		sort.Stable(sort.Reverse(Rating))
+		if opts.Debug.Hint {
+			fmt.Fprintf(w, "%v\n", Rating)
+		}
+		if HighestRatedVal := Rating.IntSlice[0]; HighestRatedVal > 0 {
+			HighestRatedIdx := Rating.indexes[0]
+			if opts.Debug.Hint {
+				fmt.Fprint(w, "@")
+				for j, Syllable := range Segment {
+					if j == HighestRatedIdx {
+						fmt.Fprint(w, opts.Debug.color(green))
+					}
+					for _, unit := range Syllable.Units {
+						fmt.Fprint(w, unit.Str)
+					}
+					if j == HighestRatedIdx {
+						fmt.Fprint(w, opts.Debug.color(ansiReset))
+					}
+				}
+			}
+			StatsAtPos := Segment.DescribeUpTo(HighestRatedIdx)
+			if beats := StatsAtPos.Long*2 + StatsAtPos.Short; beats <= BeatsDone {
+				// only possible if the spread exceeds the target
+				if report {
+					fmt.Fprintf(w, "  → %s, %q is not after the previous hint\n", noHint(), Segment[HighestRatedIdx].String())
+				}
+				return Segment
+			}
+			Segment[HighestRatedIdx].Hint = true
+			if report {
+				fmt.Fprintf(w, "  → hint on %q, the highest score\n", Segment[HighestRatedIdx].String())
+			}
+			BeatsDone = StatsAtPos.Long*2 + StatsAtPos.Short
+			Target = cfg.Target
+			if SubsegmentTotal == 1 {
+				*SegmentProcessed += 1
+			}
+			SubsegmentTotal += 1
+		} else {
+			if report {
+				fmt.Fprintf(w, "  → %s, no candidate has a positive score\n", noHint())
+			}
+			return Segment
+		}
+	}
+	if report {
+		fmt.Fprintf(w, "  → %s, %d beats left within the target %d + spread %d\n",
+			noHint(), BeatsTotal-BeatsDone, Target, MaxSpread)
+	}
+	return Segment
+}
+
+// https://stackoverflow.com/questions/31141202/get-the-indices-of-the-array-after-sorting-in-golang/31141540#31141540
+type RatingType struct {
+	sort.IntSlice
+	indexes []int
+}
+
+func (Rating RatingType) Swap(i, j int) {
+	Rating.indexes[i], Rating.indexes[j] = Rating.indexes[j], Rating.indexes[i]
+	Rating.IntSlice.Swap(i, j)
+}
+
+// rate returns the score of Segment[TargetIdx+spread] as a hint and, if
+// opts.Debug.Report is set, the terms it is made of.
+func rate(StatsTotal StatsType, Segment SegmentType, TargetIdx int, Target int, MaxSpread int, spread int, cfg HintConfig, opts Options) (int, string) {
+	w := opts.Debug.out()
+	var terms []string
+	explain := func(term string, value int) {
+		if opts.Debug.Report && value != 0 {
+			terms = append(terms, fmt.Sprintf("%s %+d", term, value))
+		}
+	}
+	wantRate, wantList := opts.Debug.Rate, opts.Debug.List
+	Syllable := Segment[TargetIdx+spread]
+	if wantRate {
+		fmt.Fprint(w, "\"")
+		for _, unit := range Syllable.Units {
+			fmt.Fprint(w, unit.Str)
+		}
+		fmt.Fprint(w, "\"\n")
+	}
+	if !Syllable.IsLong {
+		if wantRate {
+			fmt.Fprint(w, "score 0\n\n")
+		}
+		return 0, "short syllable"
+
+	}
+	var (
+		score         = cfg.BaseScore
+		listMode      = false
+		StatsAtPos    = Segment.DescribeUpTo(TargetIdx + spread)
+		beatsTotal    = StatsTotal.Long*2 + StatsTotal.Short
+		beatsAtPos    = StatsAtPos.Long*2 + StatsAtPos.Short
+		PrevTargetIdx = Segment.FindIdxMatchingBeats(beatsAtPos - Target)
+		NextTargetIdx = Segment.FindIdxMatchingBeats(beatsAtPos + Target)
+		StatsAtPrev   = Segment.DescribeUpTo(PrevTargetIdx)
+		StatsAtNext   = Segment.DescribeUpTo(NextTargetIdx)
+	)
+	//-------------------------------
+	// bonus when likely to be going through a list of items that corresponds to
+	// small words and where punctuation is likely missing
+	// pācittiya 60               = 83/20 = 4.15	DESIRED
+	// pācittiya 84               = 68/16 = 4.25	DESIRED	(case of a tiny list embedded in a sentence)
+	// pācittiya 36               = 79/18 = 4.39	?	(case of a tiny list embedded in a sentence)
+	// ajjhattikā pathavīdhātu    = 84/19 = 4.42	DESIRED
+	// ajjhattikā ākāsadhātu pt2  = 80/16 = 5.00	NOT
+	// akankheyya std formula     = 79/8  = 9.88	NOT
+	ratio := float64(beatsTotal) / float64(StatsTotal.Space)
+	if wantList && spread == 0 {
+		i := 0
+		for _, syl := range Segment {
+			for _, unit := range syl.Units {
+				if i += 1; i > 80 {
+					break
+				}
+				fmt.Fprint(w, unit.Str)
+			}
+		}
+		fmt.Fprintf(w, "\nbeatsTotal=%d\t3*Target=%d\t%t\t\tTotal.Space=%d\tratio=%.2f\t%t\n",
+			beatsTotal, cfg.ListTargets*Target, beatsTotal > cfg.ListTargets*Target,
+			StatsTotal.Space, ratio, ratio < opts.Hint)
+	}
+	explain("base", score)
+	if beatsTotal > cfg.ListTargets*Target && ratio < opts.Hint {
+		if wantList && spread == 0 {
+			fmt.Fprintln(w, opts.Debug.color(green)+"↑ IS LIST ↑"+opts.Debug.color(ansiReset))
+		}
+		if wantRate {
+			fmt.Fprintln(w, "\t[rate] List override")
+		}
+		listMode = true
+		terms = append(terms, "list")
+	}
+	//-------------------------------
+	// Penalty/bonus for surrounding spaces
+	//           │ i negative │ i positive
+	// ──────────┼────────────┼──────────────
+	// w/ space  │    ---     │     +++
+	// ──────────┼────────────┼──────────────
+	// w/o space │     +      │      0
+	penalty := 0
+	MaxSpreadSpace := cfg.MaxSpreadSpace
+	if wantRate {
+		fmt.Fprintln(w, "\t[rate] SpaceAROUND SubPenalties ")
+	}
+	for i := -MaxSpreadSpace; i <= MaxSpreadSpace; i++ {
+		j := TargetIdx + spread + i
+		if i != 0 && 0 <= j && j < len(Segment) {
+			var fullstring string
+			for _, unit := range Segment[j].Units {
+				fullstring += unit.Str
+			}
+			var factor float64
+			// negative factor = bonus
+			switch { // ContainsAny with NBSP??
+			case strings.Contains(fullstring, " ") && i < 0:
+				factor = cfg.SpaceBefore
+			case strings.Contains(fullstring, " ") && i > 1:
+				// FIXME was superseded by immediately upcomming space Bonus
+				factor = cfg.SpaceAfter * float64(MaxSpreadSpace) / float64(i)
+			case !strings.Contains(fullstring, " ") && i < 0:
+				factor = cfg.NoSpaceBefore
+			}
+			// in lists words are likely to be short, a pause suggestion in the
+			// middle of a word is unwanted
+			if listMode && factor < 0 {
+				factor = factor * cfg.ListFactor
+			}
+			subPenalty := int(float64(MaxSpreadSpace) / -float64(i) * factor)
+			penalty += subPenalty
+			if wantRate && subPenalty != 0 {
+				fmt.Fprintf(w, "\t\t\t%d due to \"%s\" at index %d (factor %d)",
+					-subPenalty, fullstring, i, int(factor))
+				if listMode && factor < 0 {
+					fmt.Fprint(w, " ", listMode)
+				}
+				fmt.Fprint(w, "\n")
+			}
+			score -= subPenalty
+		}
+	}
+	if wantRate {
+		fmt.Fprintln(w, "\t       SpaceAROUND TOTAL Penalty of", -penalty)
+	}
+	explain("spaces around", -penalty)
+	//-------------------------------
+	// FIXME is this really useful?
+	// the last part of the if checks if we're anywhere inside a long compound word
+	if !listMode && StatsTotal.Space-StatsAtPos.Space >= 0 &&
+		!(StatsAtNext.Space-StatsAtPos.Space == 0 || StatsAtPrev.Space-StatsAtPos.Space == 0) {
+		penalty := (StatsTotal.Space - StatsAtPos.Space) * cfg.SpaceLeft
+		score -= penalty
+		explain("spaces left", -penalty)
+		if wantRate {
+			fmt.Fprintln(w, "\t[rate] SpaceLEFT Penalty of", -penalty)
+		}
+	}
+	//-------------------------------
+	// penality for a pause close to the end of the segment
+	if i := beatsTotal - beatsAtPos; i < Target+MaxSpread {
+		// arbitrary func that provides the desired values: 5^(Target/0.42*(i+1))
+		// +1 to prevent a zero division panic. 0.42 = finetuned = careful
+		penalty := int(math.Pow(cfg.BorderBase, float64(Target)/(float64(i+1.0)*cfg.BorderScale)))
+		score -= penalty
+		if penalty < 0 {
+			if wantRate {
+				fmt.Fprintln(w, "\t[rate] Border Penalty: Aborting due to overflow")
+			}
+			return 0, "too close to the end of the segment"
+		}
+		explain("end of segment", -penalty)
+		if wantRate {
+			fmt.Fprintln(w, "\t[rate] Border Penalty of", -penalty)
+		}
+	}
+	//-------------------------------
+	// bonus for the the syl before the space either before a long compound word or within a list
+	var NextFullstring string
+	if i := TargetIdx + spread + 1; i < len(Segment) {
+		for _, unit := range Segment[i].Units {
+			NextFullstring += unit.Str
+		}
+	}
+	if strings.Contains(NextFullstring, " ") && (StatsAtNext.Space-StatsAtPos.Space == 1 || listMode) {
+		bonus := cfg.UpcomingSpace
+		score += bonus
+		if wantRate {
+			fmt.Fprintln(w, "\t[rate] with one immediately upcomming space Bonus of", bonus)
+		}
+		explain("upcoming space", bonus)
+	}
+	//-------------------------------
+	// bonus for the end of a member of a compound word, see Options.Lexicon
+	if Syllable.endsMember() {
+		bonus := cfg.MemberEnd
+		score += bonus
+		if wantRate {
+			fmt.Fprintln(w, "\t[rate] end of a compound member Bonus of", bonus)
+		}
+		explain("end of member", bonus)
+	}
+	//-------------------------------
+	if spread != 0 {
+		if spread < 0 {
+			spread = -spread
+		}
+		// arbitrary func that provides the desired values, by default 12x³−66x²+122x−60
+		// 		1→8     2→16     3→35     4→140
+		// w/ this it's technically possible to further increase MaxSpread (untested)
+		penalty = cfg.spreadPenalty(spread)
+		score -= penalty
+		explain("spread", -penalty)
+		if wantRate {
+			fmt.Fprintf(w, "\t[rate] Spread Penalty of %d (spread=%d)\n", -penalty, spread)
+		}
+	}
+	if wantRate {
+		fmt.Fprintln(w, "score", score)
+	}
+	return score, strings.Join(terms, ", ")
+}
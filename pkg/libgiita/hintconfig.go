@@ -0,0 +1,195 @@
+package libgiita
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// HintConfig holds the parameters of the heuristic placing the hints, see
+// MakeHint. Beats count 2 for a long syllable and 1 for a short one, spreads
+// are expressed in syllables.
+type HintConfig struct {
+	// FirstTarget is the number of beats searched for the first hint of a
+	// segment, Target that between the next ones.
+	FirstTarget, Target int
+	// MaxSpread is the radius of the search around the target.
+	MaxSpread int
+	// MaxSpreadSpace is the radius within which the spaces around a candidate are rated.
+	MaxSpreadSpace int
+	// SpaceBefore, SpaceAfter and NoSpaceBefore are the factors of the
+	// syllables around a candidate: with a space before it, with a space after
+	// it beyond the next syllable, without space before it. Negative factors
+	// are bonuses.
+	SpaceBefore, SpaceAfter, NoSpaceBefore float64
+	// ListFactor multiplies the bonuses of the syllables around a candidate in lists.
+	ListFactor float64
+	// ListTargets is the number of targets beyond which a segment is
+	// considered a list if its ratio of beats to spaces is below Options.Hint.
+	ListTargets int
+	// SpaceLeft is the penalty of a candidate inside a long compound word, per space left in the segment.
+	SpaceLeft int
+	// BorderBase and BorderScale set the penalty of a candidate close to the
+	// end of the segment: BorderBase^(target/(BorderScale*(beats left+1))).
+	BorderBase, BorderScale float64
+	// UpcomingSpace is the bonus of a candidate followed by a space.
+	UpcomingSpace int
+	// MemberEnd is the bonus of a candidate ending a member of a compound word, see Options.Lexicon.
+	MemberEnd int
+	// Spread holds the coefficients of the penalty of a candidate at a distance
+	// x from the target: Spread[0]x³ + Spread[1]x² + Spread[2]x + Spread[3].
+	Spread [4]float64
+	// BaseScore is the score of a candidate before penalties and bonuses, a
+	// hint is only placed on a positive score.
+	BaseScore int
+}
+
+// DefaultHintConfig is used if Options.HintConfig is nil.
+var DefaultHintConfig = HintConfig{
+	FirstTarget:    22,
+	Target:         13,
+	MaxSpread:      4,
+	MaxSpreadSpace: 5,
+	SpaceBefore:    30,
+	SpaceAfter:     -20,
+	NoSpaceBefore:  -3,
+	ListFactor:     10,
+	ListTargets:    3,
+	SpaceLeft:      50,
+	BorderBase:     5,
+	BorderScale:    0.42,
+	UpcomingSpace:  150,
+	MemberEnd:      150,
+	// 1→8     2→16     3→35     4→140
+	Spread:    [4]float64{12, -66, 122, -60},
+	BaseScore: 100,
+}
+
+// HintParamNames are the names of the parameters accepted by ParseHintConfig,
+// in the order of the fields of HintConfig.
+var HintParamNames = []string{
+	"firsttarget", "target", "maxspread", "maxspreadspace", "spacebefore", "spaceafter",
+	"nospacebefore", "listfactor", "listtargets", "spaceleft", "borderbase", "borderscale",
+	"upcomingspace", "memberend", "spread", "basescore",
+}
+
+func (c *HintConfig) params() map[string]any {
+	return map[string]any{
+		"firsttarget": &c.FirstTarget, "target": &c.Target, "maxspread": &c.MaxSpread,
+		"maxspreadspace": &c.MaxSpreadSpace, "spacebefore": &c.SpaceBefore, "spaceafter": &c.SpaceAfter,
+		"nospacebefore": &c.NoSpaceBefore, "listfactor": &c.ListFactor, "listtargets": &c.ListTargets,
+		"spaceleft": &c.SpaceLeft, "borderbase": &c.BorderBase, "borderscale": &c.BorderScale,
+		"upcomingspace": &c.UpcomingSpace, "memberend": &c.MemberEnd, "spread": &c.Spread,
+		"basescore": &c.BaseScore,
+	}
+}
+
+// ParseHintConfig returns DefaultHintConfig modified by s, a comma-separated
+// list of "name=value" pairs where name is one of HintParamNames, e.g.
+// "target=15, maxspread=3". The 4 coefficients of spread are separated by
+// spaces.
+func ParseHintConfig(s string) (HintConfig, error) {
+	c := DefaultHintConfig
+	params := c.params()
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, found := strings.Cut(pair, "=")
+		name, value = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(value)
+		if !found {
+			return c, fmt.Errorf("missing \"=\" in %q", pair)
+		}
+		var err error
+		switch p := params[name].(type) {
+		case *int:
+			*p, err = strconv.Atoi(value)
+		case *float64:
+			*p, err = strconv.ParseFloat(value, 64)
+		case *[4]float64:
+			coefs := strings.Fields(value)
+			if len(coefs) != len(p) {
+				return c, fmt.Errorf("%s: expected %d coefficients, got %d", name, len(p), len(coefs))
+			}
+			for i, coef := range coefs {
+				if p[i], err = strconv.ParseFloat(coef, 64); err != nil {
+					break
+				}
+			}
+		default:
+			return c, fmt.Errorf("unknown parameter %q, expected one of %s", name, strings.Join(HintParamNames, ", "))
+		}
+		if err != nil {
+			return c, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return c, c.Validate()
+}
+
+// Validate reports the values that would prevent the placement of the hints
+// from terminating or make it meaningless: values that are not finite, targets
+// below 1 beat, negative spreads or list parameters, a border penalty that
+// isn't positive and a spread penalty favoring the candidates far from the
+// target. The other factors and bonuses can take any value.
+func (c HintConfig) Validate() error {
+	for _, name := range HintParamNames {
+		var values []float64
+		switch p := c.params()[name].(type) {
+		case *float64:
+			values = []float64{*p}
+		case *[4]float64:
+			values = p[:]
+		}
+		for _, v := range values {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("%s must be finite", name)
+			}
+		}
+	}
+	switch {
+	case c.FirstTarget < 1 || c.Target < 1:
+		return fmt.Errorf("the targets must be at least 1 beat")
+	case c.MaxSpread < 0 || c.MaxSpreadSpace < 0:
+		return fmt.Errorf("the spreads can't be negative")
+	case c.ListTargets < 0 || c.ListFactor < 0:
+		return fmt.Errorf("listtargets and listfactor can't be negative")
+	case c.BorderScale <= 0 || c.BorderBase <= 0:
+		return fmt.Errorf("borderbase and borderscale must be positive")
+	}
+	for x := 1; x <= c.MaxSpread; x++ {
+		if penalty := c.spreadPenalty(x); penalty < 0 {
+			return fmt.Errorf("the spread penalty is negative at %d syllable(s) from the target: %d", x, penalty)
+		}
+	}
+	return nil
+}
+
+// spreadPenalty returns the penalty of a candidate at x syllables from the target.
+func (c HintConfig) spreadPenalty(x int) int {
+	f := float64(x)
+	return int(c.Spread[0]*math.Pow(f, 3) + c.Spread[1]*math.Pow(f, 2) + c.Spread[2]*f + c.Spread[3])
+}
+
+// String returns the configuration in the format of ParseHintConfig.
+func (c HintConfig) String() string {
+	params := c.params()
+	pairs := make([]string, len(HintParamNames))
+	for i, name := range HintParamNames {
+		var value string
+		switch p := params[name].(type) {
+		case *int:
+			value = strconv.Itoa(*p)
+		case *float64:
+			value = strconv.FormatFloat(*p, 'g', -1, 64)
+		case *[4]float64:
+			coefs := make([]string, len(p))
+			for j, coef := range p {
+				coefs[j] = strconv.FormatFloat(coef, 'g', -1, 64)
+			}
+			value = strings.Join(coefs, " ")
+		}
+		pairs[i] = name + "=" + value
+	}
+	return strings.Join(pairs, ", ")
+}
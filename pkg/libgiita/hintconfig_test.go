@@ -0,0 +1,71 @@
+package libgiita
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHintConfig(t *testing.T) {
+	c, err := ParseHintConfig(DefaultHintConfig.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != DefaultHintConfig {
+		t.Errorf("round trip of the defaults = %+v", c)
+	}
+	c, err = ParseHintConfig(" Target=15, borderscale=0.5,spread=1 2 3 4 ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultHintConfig
+	want.Target, want.BorderScale, want.Spread = 15, 0.5, [4]float64{1, 2, 3, 4}
+	if c != want {
+		t.Errorf("ParseHintConfig = %+v, want %+v", c, want)
+	}
+	for _, s := range []string{"target", "target=x", "unknown=1", "spread=1 2", "target=0", "borderscale=0",
+		"listtargets=-1", "listfactor=-2", "spread=0 0 -1 0", "basescore=1, spacebefore=NaN", "borderbase=Inf"} {
+		if _, err := ParseHintConfig(s); err == nil {
+			t.Errorf("ParseHintConfig(%q): no error", s)
+		}
+	}
+}
+
+func TestHintConfig(t *testing.T) {
+	src := "Kāyagatāsatibhāvanāsampayuttacittuppādanibbattakusalakammasamuṭṭhānarūpadhammāsammūḷhā."
+	hints := func(cfg *HintConfig, debug DebugType) (s []string) {
+		doc, err := Process(src, Options{Hint: 4.5, HintConfig: cfg, Debug: debug})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, Syllable := range doc.Paragraphs[0][0] {
+			if Syllable.Hint {
+				s = append(s, Syllable.String())
+			}
+		}
+		return
+	}
+	def := hints(nil, DebugType{})
+	if got := hints(&DefaultHintConfig, DebugType{}); strings.Join(got, " ") != strings.Join(def, " ") {
+		t.Errorf("hints with DefaultHintConfig = %q, without = %q", got, def)
+	}
+	cfg := DefaultHintConfig
+	cfg.FirstTarget, cfg.Target = 12, 8
+	if got := hints(&cfg, DebugType{}); len(got) <= len(def) {
+		t.Errorf("hints with shorter targets = %q, with the defaults %q", got, def)
+	}
+	// the spread exceeds the target
+	cfg.Target, cfg.MaxSpread = 1, 4
+	hints(&cfg, DebugType{})
+
+	var report strings.Builder
+	hints(nil, DebugType{Report: true, W: &report})
+	for _, want := range []string{"[report] hint configuration: firsttarget=22", "base +100", "→ hint on \"" + def[0] + "\"", "→ no further hint"} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("%q not found in the report:\n%s", want, report.String())
+		}
+	}
+
+	if _, err := Process(src, Options{Hint: 4.5, HintConfig: &HintConfig{}}); err == nil {
+		t.Error("invalid configuration: no error")
+	}
+}
@@ -0,0 +1,438 @@
+package libgiita
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	DefaultTemplate = `<!DOCTYPE html> <html><head>
+<title>%s</title>
+<meta charset="UTF-8">
+<style>
+%s
+</style></head>
+<body>`
+	TrainCSS = `
+.mainp {
+    margin: 0;
+    padding: 0;
+    color: black;
+    background-color: black;
+}
+
+.mainp:hover, .mainp:hover {
+  color: white;
+}`
+	DefaultCSS = `
+body {
+  font-size: %dpx;
+  line-height: 1.4em;
+  letter-spacing: -0.04em;
+  word-spacing: 0.40em;
+}
+
+%s
+
+.w {
+  white-space: nowrap;
+}
+
+.s::before{
+  content: "⸱";
+  color: #646464;
+}
+
+.punct::after{
+  content: "█";
+  color: orangered; /*#5c5c5c;*/
+}
+
+.truehigh{
+  font-weight: bold;
+  vertical-align: 13%%;
+}
+
+.long {
+}
+
+.short {
+}
+
+.hint {
+  text-decoration-line: underline;
+  text-decoration-style: wavy;
+}
+.hint::after{
+  content: "|";
+  color: orangered; /*#5c5c5c;*/
+}
+
+.cmt {
+  background: lightgrey;
+  font-style: italic;
+  word-spacing: normal;
+  font-size: 60%%;
+}
+
+.p {
+  line-height: 1.2em;
+}
+
+.optionalhigh{
+  /*font-style: italic;*/
+}
+`
+	// BookCSS is appended to the stylesheet of books
+	BookCSS = `
+.booktitle {
+  text-align: center;
+}
+
+.toc a {
+  color: inherit;
+}
+
+.chapter {
+  break-before: page;
+  page-break-before: always;
+}
+
+.chapter h2 {
+  break-after: avoid;
+  page-break-after: avoid;
+}
+
+@media print {
+  .toc a {
+    text-decoration: none;
+  }
+}
+`
+	// SplitCSS is appended to the stylesheet if the members of the compound words are separated
+	SplitCSS = `
+.cj::after{
+  content: "-";
+  color: #646464;
+}
+`
+	// OptionalLowCSS is appended to the stylesheet of documents with optional low tones
+	OptionalLowCSS = `
+.optionallow{
+  vertical-align: -10%;
+}
+`
+	// ExpandedCSS is appended to the stylesheet of documents with expanded formulas or peyyāla
+	ExpandedCSS = `
+.expanded {
+  background: rgba(255, 165, 0, 0.2);
+}
+`
+	// InterlinearCSS is appended to the stylesheet of documents with glosses
+	InterlinearCSS = `
+.row {
+  margin-bottom: 0.6em;
+}
+
+.gloss {
+  font-size: 60%;
+  line-height: 1.2em;
+  letter-spacing: normal;
+  word-spacing: normal;
+  color: #646464;
+}
+
+.side .row {
+  display: grid;
+  grid-template-columns: 3fr 2fr;
+  column-gap: 1em;
+  align-items: baseline;
+}
+`
+	rePunctCSS = regexp.MustCompile(`\n\.punct::after[^}]+}\n`)
+)
+
+// HTMLRenderer outputs a standalone HTML page in which all the formatting
+// is done through CSS classes.
+type HTMLRenderer struct {
+	Title string
+	// CSS overwrites the stylesheet built from DefaultCSS and all CSS-related options below
+	CSS      string
+	FontSize int
+	// Dark uses a white font on a dark background
+	Dark bool
+	// Samyok optimizes the CSS for chanting in the Samyok style
+	Samyok bool
+	// Noto uses noto-fonts and a slightly greater font weight for long syllables
+	Noto bool
+	// Train hides the text until it is hovered
+	Train bool
+	// Newlines is the number of linebreaks created from a single linebreak of the source
+	Newlines int
+	// Meta is recorded as an HTML comment at the top of the body if not empty,
+	// with its "--" broken up
+	Meta string
+	// DebugUnits wraps each unit in a tag whose title tells if it is relevant
+	DebugUnits bool
+	// Interlinear is the layout of the glosses: "below" the lines they belong
+	// to (default) or "side" for a column beside them.
+	Interlinear string
+	// Thai writes the syllables in Thai script, see ThaiSpelling. 0 keeps them in roman script.
+	Thai int
+	// Split separates the members of the compound words, see Options.Lexicon
+	Split bool
+}
+
+// Stylesheet returns the CSS used by the page.
+func (r HTMLRenderer) Stylesheet() string {
+	if r.CSS != "" {
+		return r.CSS
+	}
+	train := ""
+	if r.Train {
+		train = TrainCSS
+	}
+	css := fmt.Sprintf(DefaultCSS, r.FontSize, train)
+	if r.Split {
+		css += SplitCSS
+	}
+	if r.Dark {
+		css = strings.Replace(css, "body {", "body {\n  background: black;\n  color: white;", 1)
+		css = strings.Replace(css, ".s::before{\n  content: \"⸱\";\n  color: #646464;",
+			".s::before{\n  content: \"⸱\";\n  color: #858585;", 1)
+		css = strings.Replace(css, ".cmt {\n  background: lightgrey;", ".cmt {\n  background: #7E7C7C;", 1)
+	}
+	if r.Samyok {
+		css = strings.Replace(css, ".long {", ".long {\n font-weight: bold;", 1)
+		css = strings.Replace(css, ".short {", ".short {\n font-weight: 300;", 1)
+		css = strings.Replace(css, ".truehigh{", ".truehigh{\n color: yellow;", 1)
+		css = rePunctCSS.ReplaceAllString(css, "")
+	} else if r.Noto {
+		css = strings.Replace(css, "body {", "body {\n  font-family: \"Noto Sans\";", 1)
+		css = strings.Replace(css, ".long {", ".long {\n font-family: \"Noto Sans Medium\" !important;", 1)
+	}
+	return css
+}
+
+func (r HTMLRenderer) RenderDocument(w io.Writer, doc Document) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, DefaultTemplate, r.Title, r.Stylesheet()+contentCSS(doc))
+	if r.Meta != "" {
+		bw.WriteString("<!--" + commentSafe(r.Meta) + "-->\n")
+	}
+	r.renderBody(bw, doc)
+	bw.WriteString("</body></html>")
+	return bw.Flush()
+}
+
+// Chapter is a document with a title, part of a book.
+type Chapter struct {
+	Title string
+	Doc   Document
+}
+
+// RenderBook writes the chapters as a single page, with a table of contents
+// and a page break before each chapter when printing. The title of the book is r.Title.
+func (r HTMLRenderer) RenderBook(w io.Writer, Chapters []Chapter) error {
+	bw := bufio.NewWriter(w)
+	title := html.EscapeString(r.Title)
+	docs := make([]Document, len(Chapters))
+	for i, Chapter := range Chapters {
+		docs[i] = Chapter.Doc
+	}
+	fmt.Fprintf(bw, DefaultTemplate, title, r.Stylesheet()+BookCSS+contentCSS(docs...))
+	if r.Meta != "" {
+		bw.WriteString("<!--" + commentSafe(r.Meta) + "-->\n")
+	}
+	bw.WriteString("<h1 class=booktitle>" + title + "</h1>\n<nav class=toc><ol>\n")
+	for i, Chapter := range Chapters {
+		fmt.Fprintf(bw, "<li><a href=\"#chapter-%d\">%s</a></li>\n", i+1, html.EscapeString(Chapter.Title))
+	}
+	bw.WriteString("</ol></nav>\n")
+	for i, Chapter := range Chapters {
+		fmt.Fprintf(bw, "<section class=chapter id=\"chapter-%d\">\n<h2>%s</h2>\n", i+1, html.EscapeString(Chapter.Title))
+		if r.renderBody(bw, Chapter.Doc) {
+			bw.WriteString("</span>")
+		}
+		bw.WriteString("</section>\n")
+	}
+	bw.WriteString("</body></html>")
+	return bw.Flush()
+}
+
+// commentSafe breaks the "--" of s, which could end an HTML comment early
+// e.g. in the flag values of Meta, and its dashes next to the delimiters.
+func commentSafe(s string) string {
+	for strings.Contains(s, "--") {
+		s = strings.ReplaceAll(s, "--", "- -")
+	}
+	if strings.HasPrefix(s, ">") || strings.HasPrefix(s, "-") {
+		s = " " + s
+	}
+	if strings.HasSuffix(s, "-") {
+		s += " "
+	}
+	return s
+}
+
+// contentCSS returns the stylesheets needed by what the documents hold, so
+// that the pages of the others don't change.
+func contentCSS(docs ...Document) (css string) {
+	glosses, optionalLow, expanded := false, false, false
+	for _, doc := range docs {
+		glosses = glosses || len(doc.Glosses) > 0
+		optionalLow = optionalLow || doc.has(func(Syllable *SyllableType) bool { return Syllable.OptionalLow })
+		expanded = expanded || doc.has(func(Syllable *SyllableType) bool { return Syllable.Expanded })
+	}
+	if optionalLow {
+		css += OptionalLowCSS
+	}
+	if expanded {
+		css += ExpandedCSS
+	}
+	if glosses {
+		css += InterlinearCSS
+	}
+	return
+}
+
+// has reports whether a syllable of doc satisfies f.
+func (doc Document) has(f func(*SyllableType) bool) bool {
+	for _, Paragraph := range doc.Paragraphs {
+		for _, Segment := range Paragraph {
+			for h := range Segment {
+				if f(&Segment[h]) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// renderBody writes the paragraphs of doc, without any header, as a
+// succession of <p class=mainp>. If doc has glosses, the paragraphs are
+// instead <div class="mainp il"> made of rows holding the lines of the
+// source and the gloss following them. It reports whether the span of the
+// last word is left open, which single documents tolerate but books must close
+// before the next chapter.
+func (r HTMLRenderer) renderBody(bw *bufio.Writer, doc Document) (openword bool) {
+	// the \n makes the html source somewhat readable
+	newline := strings.Repeat("<br>\n", r.Newlines)
+	span := "<span class=\"%s\">"
+	cmts := newCmtIterator(doc)
+	interlinear, rowOpen := len(doc.Glosses) > 0, false
+	// rows are opened by their first visible unit
+	openRow := func() {
+		if interlinear && !rowOpen {
+			bw.WriteString("<div class=row><div class=pali>")
+			rowOpen = true
+		}
+	}
+	closeRow := func(gloss string) {
+		if openword {
+			bw.WriteString("</span>")
+			openword = false
+		}
+		if rowOpen {
+			bw.WriteString("</div><div class=gloss>" + strings.ReplaceAll(html.EscapeString(gloss), "\n", "<br>") + "</div></div>\n")
+			rowOpen = false
+		}
+	}
+	for _, Paragraph := range doc.Paragraphs {
+		switch {
+		case interlinear && r.Interlinear == "side":
+			bw.WriteString("<div class=\"mainp il side\">")
+		case interlinear:
+			bw.WriteString("<div class=\"mainp il\">")
+		default:
+			// left open, the next <p> closes it
+			bw.WriteString("<p class=mainp>")
+		}
+		for _, Segment := range Paragraph {
+			for h, Syllable := range Segment {
+				class := ""
+				if !Syllable.Irrelevant {
+					openRow()
+				}
+				// TODO Implements Word type in addition to Segment
+				if Syllable.Irrelevant && openword {
+					bw.WriteString("</span>")
+					openword = false
+					// TODO add counter for "openword" and add <span class=spoiler>
+				} else if !Syllable.Irrelevant && !openword {
+					fmt.Fprintf(bw, span, "w")
+					openword = true
+				}
+				class += whichTone(&Syllable)
+				if Syllable.IsLong {
+					class = appendClass(class, "long")
+				} else if !Syllable.Irrelevant {
+					class = appendClass(class, "short")
+				}
+				if Syllable.Hint {
+					class = appendClass(class, "hint")
+				}
+				if Syllable.Expanded {
+					class = appendClass(class, "expanded")
+				}
+				if class != "" {
+					fmt.Fprintf(bw, span, class)
+				}
+				// TODO closs span class spoiler at the end of the paragraph
+				spelled := false
+				for _, unit := range Syllable.Units {
+					if interlinear && !rowOpen && !ReSpace.MatchString(unit.Str) {
+						openRow()
+					}
+					switch {
+					case interlinear && !rowOpen:
+						// blanks between the rows
+					case cmts.isMark(unit.Str) && unit.Str == GlossMark:
+						closeRow(cmts.next(unit.Str))
+					case strings.Contains(unit.Str, "\n"):
+						// FIXME one empty newline = two \n, so -l 2 is a factor 2 operation, need a smaller step
+						bw.WriteString(strings.ReplaceAll(unit.Str, "\n", newline))
+					case ReSpace.MatchString(unit.Str):
+						bw.WriteString(" ")
+					case RePunc.MatchString(unit.Str) && !ReIsExceptPunct.MatchString(unit.Str):
+						bw.WriteString(html.EscapeString(unit.Str) + "<span class=punct></span>")
+					case cmts.isMark(unit.Str):
+						if unit.Str == CmtParaMark {
+							bw.WriteString("\n<p class=\"cmt p\">" + html.EscapeString(cmts.next(unit.Str)) + "</p>")
+						} else {
+							bw.WriteString("<span class=cmt>" + html.EscapeString(cmts.next(unit.Str)) + "</span>")
+						}
+					case r.Thai != 0 && unit.isLetter():
+						if !spelled {
+							bw.WriteString(html.EscapeString(Syllable.ThaiSpelling(r.Thai)))
+							spelled = true
+						}
+					case r.DebugUnits:
+						bw.WriteString(`<dfn title="` + strconv.FormatBool(unit.IsRelevant()) + `">` + html.EscapeString(unit.Str) + `</dfn>`)
+					default:
+						bw.WriteString(html.EscapeString(unit.Str))
+					}
+				}
+				if class != "" {
+					bw.WriteString("</span>")
+				}
+				if r.Split && Syllable.endsMember() {
+					bw.WriteString("<span class=cj></span>")
+				} else if Segment.NeedsSeparator(h) {
+					bw.WriteString("<span class=s></span>")
+				}
+			}
+		}
+		if interlinear {
+			closeRow("")
+			bw.WriteString("</div>\n")
+		}
+	}
+	return
+}
@@ -0,0 +1,77 @@
+package libgiita
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderBook(t *testing.T) {
+	doc, err := Process("namo tassa", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err = (HTMLRenderer{Newlines: 1}).RenderBook(&b, []Chapter{{"1", doc}, {"2", doc}}); err != nil {
+		t.Fatal(err)
+	}
+	// the span of the last word is closed before the end of the chapter
+	if got := strings.Count(b.String(), "sa</span></span></section>"); got != 2 {
+		t.Errorf("%d chapters closing their last word, want 2:\n%s", got, b.String())
+	}
+	// single documents are left as they always were
+	b.Reset()
+	if err = (HTMLRenderer{Newlines: 1}).RenderDocument(&b, doc); err != nil {
+		t.Fatal(err)
+	}
+	if want := "sa</span></body></html>"; !strings.HasSuffix(b.String(), want) {
+		t.Errorf("document ending %q, want %q", b.String()[len(b.String())-len(want):], want)
+	}
+}
+
+func TestContentCSS(t *testing.T) {
+	tests := []struct {
+		src   string
+		opts  Options
+		r     HTMLRenderer
+		class string
+		want  bool
+	}{
+		{"sukho hotu", Options{}, HTMLRenderer{}, ".optionallow", false},
+		{"sukho hotu", Options{OptionalLow: true}, HTMLRenderer{}, ".optionallow", true},
+		{"sukho hotu", Options{ExpandPe: true}, HTMLRenderer{}, ".expanded ", false},
+		{"rūpaṃ aniccaṃ ... pe ... vedanā.", Options{ExpandPe: true}, HTMLRenderer{}, ".expanded ", true},
+		{"dhammacakkaṁ", Options{Lexicon: DefaultLexicon}, HTMLRenderer{}, ".cj::after", false},
+		{"dhammacakkaṁ", Options{Lexicon: DefaultLexicon}, HTMLRenderer{Split: true}, ".cj::after", true},
+	}
+	for _, test := range tests {
+		doc, err := Process(test.src, test.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		if err = test.r.RenderDocument(&b, doc); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(b.String(), test.class+"{"); got != test.want {
+			t.Errorf("%q %+v %+v: %s in the stylesheet = %v, want %v", test.src, test.opts, test.r, test.class, got, test.want)
+		}
+	}
+}
+
+func TestMeta(t *testing.T) {
+	meta := "giita -re=a-->b<!-- -c=---"
+	for _, render := range []func(HTMLRenderer, *strings.Builder) error{
+		func(r HTMLRenderer, b *strings.Builder) error { return r.RenderDocument(b, Document{}) },
+		func(r HTMLRenderer, b *strings.Builder) error { return r.RenderBook(b, nil) },
+	} {
+		var b strings.Builder
+		if err := render(HTMLRenderer{Meta: meta}, &b); err != nil {
+			t.Fatal(err)
+		}
+		_, after, _ := strings.Cut(b.String(), "<body><!--")
+		cmt, rest, _ := strings.Cut(after, "-->")
+		if want := "giita -re=a- ->b<!- - -c=- - - "; cmt != want || strings.Contains(rest, "b<!") {
+			t.Errorf("comment %q, want %q", cmt, want)
+		}
+	}
+}
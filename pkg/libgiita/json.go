@@ -0,0 +1,178 @@
+package libgiita
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSONSchemaVersion is the version of the JSON representation of a Document
+// described in schema/v1.json. It is incremented on any incompatible change.
+const JSONSchemaVersion = 1
+
+// TypeNames maps the types of units to their name in the JSON schema.
+var TypeNames = map[int]string{
+	LongVwl:     "longvowel",
+	ShortVwl:    "shortvowel",
+	Cons:        "consonant",
+	ElisionMark: "elision",
+	Punct:       "punct",
+	Space:       "space",
+	Other:       "other",
+}
+
+type jsonDocument struct {
+	Schema     string          `json:"schema"`
+	Version    int             `json:"version"`
+	Comments   jsonComments    `json:"comments"`
+	Glosses    []string        `json:"glosses,omitempty"`
+	Paragraphs []jsonParagraph `json:"paragraphs"`
+}
+
+type jsonComments struct {
+	Para []string `json:"para"`
+	Span []string `json:"span"`
+}
+
+type jsonParagraph struct {
+	Segments []jsonSegment `json:"segments"`
+}
+
+type jsonSegment struct {
+	Syllables []jsonSyllable `json:"syllables"`
+}
+
+type jsonSyllable struct {
+	Text         string     `json:"text"`
+	Units        []jsonUnit `json:"units"`
+	IsLong       bool       `json:"isLong"`
+	TrueHigh     bool       `json:"trueHigh"`
+	OptionalHigh bool       `json:"optionalHigh"`
+	OptionalLow  bool       `json:"optionalLow,omitempty"`
+	Hint         bool       `json:"hint"`
+	Expanded     bool       `json:"expanded,omitempty"`
+	ClosingPara  bool       `json:"closingPara"`
+}
+
+type jsonUnit struct {
+	Str       string   `json:"str"`
+	Type      string   `json:"type"`
+	Closing   bool     `json:"closing"`
+	MemberEnd bool     `json:"memberEnd,omitempty"`
+	Pos       *jsonPos `json:"pos,omitempty"`
+}
+
+type jsonPos struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Col    int `json:"col"`
+}
+
+// JSONRenderer outputs the syllable analysis of a document as JSON, see
+// schema/v1.json. The output can be read back with DecodeJSON.
+type JSONRenderer struct {
+	// Indent is used to indent nested elements, the output is compact if empty
+	Indent string
+}
+
+func (r JSONRenderer) RenderDocument(w io.Writer, doc Document) error {
+	jd := jsonDocument{
+		Schema:     "giita",
+		Version:    JSONSchemaVersion,
+		Comments:   jsonComments{Para: doc.CmtsPara, Span: doc.CmtsSpan},
+		Glosses:    doc.Glosses,
+		Paragraphs: []jsonParagraph{},
+	}
+	for _, Paragraph := range doc.Paragraphs {
+		var jp jsonParagraph
+		for _, Segment := range Paragraph {
+			var js jsonSegment
+			for _, Syllable := range Segment {
+				jsyl := jsonSyllable{
+					Text:         Syllable.String(),
+					IsLong:       Syllable.IsLong,
+					TrueHigh:     Syllable.TrueHigh,
+					OptionalHigh: Syllable.OptionalHigh,
+					OptionalLow:  Syllable.OptionalLow,
+					Hint:         Syllable.Hint,
+					Expanded:     Syllable.Expanded,
+					ClosingPara:  Syllable.ClosingPara,
+				}
+				for _, unit := range Syllable.Units {
+					ju := jsonUnit{Str: unit.Str, Type: TypeNames[unit.Type], Closing: unit.Closing, MemberEnd: unit.MemberEnd}
+					if unit.Pos.Line != 0 {
+						ju.Pos = &jsonPos{unit.Pos.Offset, unit.Pos.Line, unit.Pos.Col}
+					}
+					jsyl.Units = append(jsyl.Units, ju)
+				}
+				js.Syllables = append(js.Syllables, jsyl)
+			}
+			jp.Segments = append(jp.Segments, js)
+		}
+		jd.Paragraphs = append(jd.Paragraphs, jp)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", r.Indent)
+	return enc.Encode(jd)
+}
+
+// DecodeJSON reads a document previously exported by JSONRenderer, possibly
+// corrected by hand, so that it can be rendered again. The "text" of syllables
+// is informative only: the units are authoritative.
+func DecodeJSON(r io.Reader) (doc Document, err error) {
+	var jd jsonDocument
+	if err = json.NewDecoder(r).Decode(&jd); err != nil {
+		return doc, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	if jd.Schema != "giita" {
+		return doc, fmt.Errorf("invalid JSON document: unknown schema %q", jd.Schema)
+	}
+	if jd.Version != JSONSchemaVersion {
+		return doc, fmt.Errorf("unsupported JSON schema version %d (expected %d)", jd.Version, JSONSchemaVersion)
+	}
+	types := make(map[string]int)
+	for t, name := range TypeNames {
+		types[name] = t
+	}
+	doc.CmtsPara, doc.CmtsSpan = jd.Comments.Para, jd.Comments.Span
+	doc.Glosses = jd.Glosses
+	for i, jp := range jd.Paragraphs {
+		var Paragraph ParagraphType
+		for j, js := range jp.Segments {
+			var Segment SegmentType
+			for k, jsyl := range js.Syllables {
+				if len(jsyl.Units) == 0 {
+					return doc, fmt.Errorf("paragraph %d, segment %d, syllable %d: no units", i, j, k)
+				}
+				Syllable := SyllableType{
+					IsLong:       jsyl.IsLong,
+					TrueHigh:     jsyl.TrueHigh,
+					OptionalHigh: jsyl.OptionalHigh,
+					OptionalLow:  jsyl.OptionalLow,
+					Hint:         jsyl.Hint,
+					Expanded:     jsyl.Expanded,
+					ClosingPara:  jsyl.ClosingPara,
+				}
+				for _, ju := range jsyl.Units {
+					t, ok := types[ju.Type]
+					if !ok {
+						return doc, fmt.Errorf("paragraph %d, segment %d, syllable %d: unknown unit type %q", i, j, k, ju.Type)
+					}
+					unit := UnitType{Str: ju.Str, Type: t, Closing: ju.Closing, MemberEnd: ju.MemberEnd}
+					if ju.Pos != nil {
+						unit.Pos = Pos{ju.Pos.Offset, ju.Pos.Line, ju.Pos.Col}
+					}
+					Syllable.Units = append(Syllable.Units, unit)
+					Syllable.Relevant = Syllable.Relevant || unit.IsRelevant()
+				}
+				// same as SetTones: the last unit decides
+				Syllable.Irrelevant = !Syllable.Units[len(Syllable.Units)-1].IsRelevant()
+				Segment = append(Segment, Syllable)
+			}
+			Paragraph = append(Paragraph, Segment)
+		}
+		doc.Paragraphs = append(doc.Paragraphs, Paragraph)
+	}
+	return
+}
@@ -0,0 +1,48 @@
+package libgiita
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	doc, err := Process("namo tassa.\n\nbuddhaṃ saraṇaṃ gacchāmi.", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err = (JSONRenderer{}).RenderDocument(&b, doc); err != nil {
+		t.Fatal(err)
+	}
+	back, err := DecodeJSON(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back.Paragraphs) != 2 {
+		t.Fatalf("%d paragraphs read back, want 2", len(back.Paragraphs))
+	}
+	var closing []string
+	for p, Paragraph := range back.Paragraphs {
+		for _, Segment := range Paragraph {
+			for _, Syllable := range Segment {
+				if Syllable.ClosingPara {
+					closing = append(closing, strings.Repeat("¶", p+1)+Syllable.String())
+				}
+			}
+		}
+	}
+	if want := "¶.\n\n"; strings.Join(closing, "|") != want {
+		t.Errorf("closing syllables = %q, want %q", closing, want)
+	}
+
+	var orig, decoded strings.Builder
+	if err = (HTMLRenderer{Newlines: 1}).RenderDocument(&orig, doc); err != nil {
+		t.Fatal(err)
+	}
+	if err = (HTMLRenderer{Newlines: 1}).RenderDocument(&decoded, back); err != nil {
+		t.Fatal(err)
+	}
+	if orig.String() != decoded.String() {
+		t.Errorf("html of the decoded document differs:\n%s\n%s", orig.String(), decoded.String())
+	}
+}
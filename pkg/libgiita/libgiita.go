@@ -3,6 +3,7 @@ package libgiita
 import (
 	"regexp"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 	
 	//"github.com/gookit/color"
@@ -36,18 +37,18 @@ var (
 	LongVwls    = []string{"ā", "e", "ī", "o", "ū"} /*, "ay"} too many false positives */
 	ShortVwls   = []string{"a", "i", "u"}
 	VowelTypes  = []int{LongVwl, ShortVwl}
-	reLongVwls  []*regexp.Regexp
-	reShortVwls []*regexp.Regexp
 
 	C = []string{"bh", "dh", "ḍh", "gh", "jh", "kh", "ph", "th", "ṭh", "sm",
 		"ch", "c", "g", "h", "s", "j", "r", "p", "b", "d", "k", "t", "ṭ",
 		 "m", "ṁ", "ṃ", "n", "ñ", "ṅ", "ṇ", "y", "l", "ḷ", "ḍ", "v"}
-	reC []*regexp.Regexp
 
 	NeverLastPos      = []string{"bh", "dh", "ḍh", "gh", "jh", "kh", "ph", "th", "ṭh", "v", "r"}
 	UnstopChar        = []string{"n", "ñ", "ṅ", "ṇ", "m", "ṁ", "ṃ", "l", "ḷ", "y"}
 	HighToneFirstChar = []string{"ch", "th", "ṭh", "kh", "ph", "sm", "s", "h"}
 	OptHighFirstChar  = []string{"v", "bh", "r", "n", "ṇ", "m", "y"}
+	// consonants of the Thai middle class, which take a low tone in a stopped syllable
+	// just like those of the high class
+	OptLowFirstChar = []string{"k", "c", "ṭ", "t", "p"}
 )
 
 
@@ -56,14 +57,23 @@ type UnitType struct {
 	Type    int
 	Len     string
 	Closing bool
+	// position in the source text
+	Pos Pos
+	// MemberEnd is set on the last unit of the syllable ending a member of a compound word, see Options.Lexicon
+	MemberEnd bool
+	// set by Exceptions.Apply
+	force int
+	tone  byte
 }
 
 type SyllableType struct {
 	Units                                    []UnitType
 	IsLong, NotStopped, HasHighToneFirstChar bool
 	Irrelevant, Relevant, Hint                         bool // FIXME
-	TrueHigh, OptionalHigh                   bool
+	TrueHigh, OptionalHigh, OptionalLow      bool
 	ClosingPara                              bool
+	// Expanded is set on the syllables added by the expansion of a formula or a "... pe ..."
+	Expanded bool
 }
 
 type SegmentType []SyllableType
@@ -72,68 +82,35 @@ type ParagraphType []SegmentType
 
 
 
-func init() {
-	for _, ShortVwl := range ShortVwls {
-		re := regexp.MustCompile("(?i)^" + ShortVwl)
-		reShortVwls = append(reShortVwls, re)
-	}
-	for _, LongVwl := range LongVwls {
-		re := regexp.MustCompile("(?i)^" + LongVwl)
-		reLongVwls = append(reLongVwls, re)
-	}
-	for _, Cons := range C {
-		re := regexp.MustCompile("(?i)^" + Cons)
-		reC = append(reC, re)
-	}
-}
-
-
-// first try w/ the list of short, long vwl and cons in lower case,
-// frequently encountered punctuation, space/newline/nbsp, digits then try with regex.
-// On average 97% of the parsing can be accomplised without using regex.
+// Parser splits src into units in a single pass. The lists of letters,
+// punctuation, spaces and digits are matched first, the longest match winning,
+// then letters regardless of their case, runs of punctuation or spaces and
+// finally any other character on its own.
 func Parser(src string) (RawUnits []UnitType) {
-	f := func(m string, src *string, i int) (u UnitType) {
-		u = UnitType{Str: m, Type: i}
-		*src = strings.TrimPrefix(*src, m)
-		return
-	}
-	var StrMatch, Done float64
-	Lists := [][]string{LongVwls, ShortVwls, C, FrequentElisionMark, FrequentPunc, FrequentSpace, FrequentOther}
-	reLists := [][]*regexp.Regexp{reLongVwls, reShortVwls, reC, nil, {RePunc}, {ReSpace}}
-	// Note: rewrite with generics = func 13% more CPU intensive + more than twice the length
-	// archived in commit bc90408901aed35032ced3ca31e3ea7a8ad2cf2e
-Outerloop:
-	for src != "" {
-		found := false
-		Done += 1
-		for i, list := range Lists {
-			for _, s := range list {
-				if found = strings.HasPrefix(src, s); found {
-					RawUnits = append(RawUnits, f(s, &src, i))
-					StrMatch += 1
-					continue Outerloop
-				}
-			}
+	// a unit is at least one rune
+	RawUnits = make([]UnitType, 0, utf8.RuneCountInString(src))
+	pos := Pos{Line: 1, Col: 1}
+	for i := 0; i < len(src); {
+		size, typ := unitTrie.match(src[i:])
+		if size == 0 {
+			size, typ = letterTrie.match(src[i:])
 		}
-		for i, list := range reLists {
-			for _, re := range list {
-				if found = re.MatchString(src); found {
-					m := re.FindString(src)
-					RawUnits = append(RawUnits, f(m, &src, i))
-					continue Outerloop
-				}
-			}
+		if size == 0 {
+			size, typ = runLength(src[i:], unicode.IsPunct), Punct
+		}
+		if size == 0 {
+			size, typ = runLength(src[i:], isSpace), Space
 		}
-		r, _ := utf8.DecodeRuneInString(src)
-		char := string(r)
-		RawUnits = append(RawUnits, f(char, &src, Other))
-		/*if wantDebug.Parser { // && char != CmtParaMark && char != CmtSpanMark {
-			fmt.Printf("'%s': Non-Pali/Unknown Char (%U)\n", char, r)
-		}*/
+		if size == 0 {
+			// an invalid byte is a unit of its own
+			_, size = utf8.DecodeRuneInString(src[i:])
+			typ = Other
+		}
+		unit := UnitType{Str: src[i : i+size], Type: typ, Pos: pos}
+		RawUnits = append(RawUnits, unit)
+		pos = pos.advance(unit.Str)
+		i += size
 	}
-	/*if wantDebug.Parser || wantDebug.Stats {
-		fmt.Printf("[parser] String match account for %.1f%% of all matches (%d/%d)\n", StrMatch/Done*100, int(StrMatch), int(Done))
-	}*/
 	return
 }
 
@@ -196,6 +173,12 @@ func SyllableBuilder(Units []UnitType) []SyllableType {
 		} else {
 			unit.Closing = false
 		}
+		switch unit.force {
+		case forceClose:
+			unit.Closing, mustReject = true, false
+		case forceOpen:
+			unit.Closing = false
+		}
 		if !PrevUnit.IsRelevant() && unit.IsRelevant() {
 			Syllables = append(Syllables, Syllable)
 			Syllable = *new(SyllableType)
@@ -219,8 +202,8 @@ func (unit UnitType) IsRelevant() (b bool) {
 	return !contains(IrrelevantTypes, unit.Type)
 	/*if !b && ReIsExceptPunct.MatchString(unit.Str) {
 		b = true
-	}*/
-	return
+	}
+	return*/
 }
 
 
@@ -272,12 +255,8 @@ func (Segment *SegmentType) SyllableString() (s string) {
 		for _, Unit := range Syllable.Units {
 			s += Unit.Str
 		}
-		if h < len(*Segment)-1 {
-			lastUnit := Syllable.Units[len(Syllable.Units)-1]
-			NextSylFirstUnit := Syllables[h+1].Units[0]
-			if lastUnit.IsRelevant() && NextSylFirstUnit.IsRelevant() {
-				s += "⸱"
-			}
+		if Syllables.NeedsSeparator(h) {
+			s += "⸱"
 		}
 	}
 	return
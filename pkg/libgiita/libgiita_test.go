@@ -0,0 +1,175 @@
+package libgiita
+
+import (
+	"strings"
+	"testing"
+)
+
+// units formats units as "str:type" separated by spaces, using the type names of the JSON schema
+func units(Units []UnitType) string {
+	var s []string
+	for _, unit := range Units {
+		s = append(s, unit.Str+":"+TypeNames[unit.Type])
+	}
+	return strings.Join(s, " ")
+}
+
+// split joins the syllables with the separator used by the text output
+func split(Syllables []SyllableType) string {
+	var s []string
+	for _, Syllable := range Syllables {
+		s = append(s, Syllable.String())
+	}
+	return strings.Join(s, "⸱")
+}
+
+// syllables runs the pipeline up to SetTones on a word
+func syllables(word string) []SyllableType {
+	return SetTones(SyllableBuilder(Parser(word)))
+}
+
+func TestParser(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"namo", "n:consonant a:shortvowel m:consonant o:longvowel"},
+		{"bhagavā", "bh:consonant a:shortvowel g:consonant a:shortvowel v:consonant ā:longvowel"},
+		{"saṅgho", "s:consonant a:shortvowel ṅ:consonant gh:consonant o:longvowel"},
+		{"ṭhānaṁ", "ṭh:consonant ā:longvowel n:consonant a:shortvowel ṁ:consonant"},
+		{"Buddho", "B:consonant u:shortvowel d:consonant dh:consonant o:longvowel"},
+		{"hī’ti.", "h:consonant ī:longvowel ’:elision t:consonant i:shortvowel .:punct"},
+		{"so, ca", "s:consonant o:longvowel ,:punct  :space c:consonant a:shortvowel"},
+		{"a\n\nb", "a:shortvowel \n:space \n:space b:consonant"},
+		{"— 12 x", "—:punct  :space 1:other 2:other  :space x:other"},
+	}
+	for _, tt := range tests {
+		if got := units(Parser(tt.src)); got != tt.want {
+			t.Errorf("Parser(%q):\n got %q\nwant %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestSyllableBuilder(t *testing.T) {
+	tests := []struct {
+		word, want string
+	}{
+		{"namo", "na⸱mo"},
+		{"bhagavato", "bha⸱ga⸱va⸱to"},
+		{"arahato", "a⸱ra⸱ha⸱to"},
+		{"sammāsambuddhassa", "sam⸱mā⸱sam⸱bud⸱dhas⸱sa"},
+		{"buddhaṁ", "bud⸱dhaṁ"},
+		{"saraṇaṁ", "sa⸱ra⸱ṇaṁ"},
+		{"gacchāmi", "gac⸱chā⸱mi"},
+		{"saṅgho", "saṅ⸱gho"},
+		{"sandiṭṭhiko", "san⸱diṭ⸱ṭhi⸱ko"},
+		{"ñāyapaṭipanno", "ñā⸱ya⸱pa⸱ṭi⸱pan⸱no"},
+		{"puttamāyusā", "put⸱ta⸱mā⸱yu⸱sā"},
+		{"bhikkhave", "bhik⸱kha⸱ve"},
+	}
+	for _, tt := range tests {
+		if got := split(SyllableBuilder(Parser(tt.word))); got != tt.want {
+			t.Errorf("SyllableBuilder(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestSyllableBuilderClosing(t *testing.T) {
+	// the unit closing each syllable is marked as such
+	for _, Syllable := range SyllableBuilder(Parser("sammāsambuddhassa")) {
+		last := Syllable.Units[len(Syllable.Units)-1]
+		if !last.Closing {
+			t.Errorf("%q: last unit %q is not closing", Syllable.String(), last.Str)
+		}
+	}
+}
+
+// tones formats the tones of each syllable: H true high, h optional high,
+// l optional low, - none
+func tones(Syllables []SyllableType) string {
+	var b strings.Builder
+	for _, Syllable := range Syllables {
+		switch {
+		case Syllable.TrueHigh:
+			b.WriteByte('H')
+		case Syllable.OptionalHigh:
+			b.WriteByte('h')
+		case Syllable.OptionalLow:
+			b.WriteByte('l')
+		default:
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// lengths formats the length of each syllable: L long, S short
+func lengths(Syllables []SyllableType) string {
+	var b strings.Builder
+	for _, Syllable := range Syllables {
+		if Syllable.IsLong {
+			b.WriteByte('L')
+		} else {
+			b.WriteByte('S')
+		}
+	}
+	return b.String()
+}
+
+func TestSetTones(t *testing.T) {
+	tests := []struct {
+		word, lengths, tones string
+	}{
+		{"namo", "SL", "h-"},
+		{"bhagavato", "SSSL", "h-h-"},
+		{"sammāsambuddhassa", "LLLLLS", "H-H---"},
+		{"buddhaṁ", "LL", "--"},
+		{"saraṇaṁ", "SSL", "-h-"},
+		{"gacchāmi", "LLS", "-Hh"},
+		{"saṅgho", "LL", "H-"},
+		{"khīṇaṁ", "LL", "H-"},
+		{"hotu", "LS", "H-"},
+		{"nibbānaṁ", "LLL", "h--"},
+		{"bhikkhave", "LSL", "h--"},
+		{"mettaṁ", "LL", "--"},
+	}
+	for _, tt := range tests {
+		Syllables := syllables(tt.word)
+		if got := lengths(Syllables); got != tt.lengths {
+			t.Errorf("SetTones(%q) lengths = %q, want %q", tt.word, got, tt.lengths)
+		}
+		if got := tones(Syllables); got != tt.tones {
+			t.Errorf("SetTones(%q) tones = %q, want %q", tt.word, got, tt.tones)
+		}
+	}
+}
+
+func TestSetOptionalLow(t *testing.T) {
+	tests := []struct {
+		word, tones string
+	}{
+		{"sammāsambuddhassa", "H-H--l"},
+		{"saraṇaṁ", "lh-"},
+		{"paccattaṁ", "ll-"},
+		{"idha", "l-"},
+		{"ehipassiko", "-lll-"},
+		{"hotu", "Hl"},
+		{"mettaṁ", "--"},
+	}
+	for _, tt := range tests {
+		if got := tones(SetOptionalLow(syllables(tt.word))); got != tt.tones {
+			t.Errorf("SetOptionalLow(%q) = %q, want %q", tt.word, got, tt.tones)
+		}
+	}
+}
+
+func TestSegmentBuilder(t *testing.T) {
+	Segments := SegmentBuilder(syllables("namo tassa, bhagavato.\narahato"))
+	var got []string
+	for _, Segment := range Segments {
+		got = append(got, Segment.String())
+	}
+	want := []string{"namo tassa, ", "bhagavato.\n", "arahato"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("SegmentBuilder = %q, want %q", got, want)
+	}
+}
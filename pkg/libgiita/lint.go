@@ -0,0 +1,179 @@
+package libgiita
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	Warning = iota
+	Error
+)
+
+// SeverityNames maps the severities of diagnostics to their name
+var SeverityNames = map[int]string{
+	Warning: "warning",
+	Error:   "error",
+}
+
+// Diagnostic is a problem found in a source text by Lint.
+type Diagnostic struct {
+	Pos      Pos
+	Severity int
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.Pos, SeverityNames[d.Severity], d.Message)
+}
+
+// Lint reports the suspicious parts of src: characters that are not Pali,
+// words without vowel, consonant clusters the syllable builder cannot split
+// cleanly, unbalanced comment marks, ellipses (except the "... pe ..."
+// expanded if Options.ExpandPe is set), a mix of ṃ and ṁ and ASCII
+// apostrophes used instead of ’. Comments and glosses are not checked. The
+// diagnostics are sorted by position.
+func Lint(src string, opts Options) (diags []Diagnostic, err error) {
+	p := newPositioner(src)
+	// ranges of src whose text is not checked: the glosses, extracted first as
+	// in Process, are not Pali
+	var skipped [][]int
+	if opts.Gloss != "" {
+		skipped, _ = findGlosses(src, opts.Gloss)
+	}
+	// comments end at the first closing mark as in Process, their text is not checked
+	if len(opts.CmtMarks) == 3 {
+		var open []Pos
+		glosses := skipped
+		for i := 0; i < len(src); i++ {
+			if len(glosses) > 0 && i >= glosses[0][0] {
+				i = glosses[0][1] - 1
+				glosses = glosses[1:]
+				continue
+			}
+			switch {
+			case src[i] == opts.CmtMarks[0] && (len(open) == 0 || opts.CmtMarks[0] != opts.CmtMarks[2]):
+				if len(open) > 0 {
+					diags = append(diags, Diagnostic{p.pos(i), Error, fmt.Sprintf("nested comment mark %q in the comment opened at %s",
+						opts.CmtMarks[0:1], open[0])})
+				}
+				open = append(open, p.pos(i))
+			case src[i] == opts.CmtMarks[2] && len(open) == 0:
+				diags = append(diags, Diagnostic{p.pos(i), Error, fmt.Sprintf("comment mark %q closes no comment", opts.CmtMarks[2:3])})
+			case src[i] == opts.CmtMarks[2]:
+				skipped = append(skipped, []int{open[0].Offset, i + 1})
+				open = nil
+			}
+		}
+		if len(open) > 0 {
+			diags = append(diags, Diagnostic{open[0], Error, "comment is not closed"})
+		}
+	}
+	opts.Hint = 0
+	doc, err := Process(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	// the abbreviations expanded leave nothing incomplete
+	skipped = append(skipped, doc.pe...)
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i][0] < skipped[j][0] })
+	// source-level checks, in a single pass over src
+	var dotNiggahita, abovedNiggahita []Pos
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case len(skipped) > 0 && i >= skipped[0][0]:
+			// a gloss may lie within a comment
+			size = max(skipped[0][1]-i, 0)
+			skipped = skipped[1:]
+		case strings.HasPrefix(src[i:], "..."):
+			diags = append(diags, Diagnostic{p.pos(i), Warning, "ellipsis, the chanting text could be incomplete"})
+			size = len("...")
+		case r == '…':
+			diags = append(diags, Diagnostic{p.pos(i), Warning, "ellipsis, the chanting text could be incomplete"})
+		case r == 'ṃ' || r == 'Ṃ':
+			dotNiggahita = append(dotNiggahita, p.pos(i))
+		case r == 'ṁ' || r == 'Ṁ':
+			abovedNiggahita = append(abovedNiggahita, p.pos(i))
+		case r == '\'':
+			diags = append(diags, Diagnostic{p.pos(i), Warning, "ASCII apostrophe, elisions are marked with ’"})
+		}
+		i += size
+	}
+	if len(dotNiggahita) > 0 && len(abovedNiggahita) > 0 {
+		minority, majority, name := dotNiggahita, abovedNiggahita, "ṃ"
+		if len(abovedNiggahita) < len(dotNiggahita) {
+			minority, majority, name = abovedNiggahita, dotNiggahita, "ṁ"
+		}
+		for _, pos := range minority {
+			diags = append(diags, Diagnostic{pos, Warning, fmt.Sprintf("%s mixed with %d occurrence(s) of %s",
+				name, len(majority), map[string]string{"ṃ": "ṁ", "ṁ": "ṃ"}[name])})
+		}
+	}
+	// analysis-level checks
+	var Units []UnitType
+	for _, Paragraph := range doc.Paragraphs {
+		for _, Segment := range Paragraph {
+			for _, Syllable := range Segment {
+				Units = append(Units, Syllable.Units...)
+			}
+		}
+	}
+	for _, unit := range Units {
+		if unit.Type == Other && !contains(FrequentOther, unit.Str) &&
+			unit.Str != CmtParaMark && unit.Str != CmtSpanMark && unit.Str != GlossMark {
+			r, _ := utf8.DecodeRuneInString(unit.Str)
+			diags = append(diags, Diagnostic{unit.Pos, Error, fmt.Sprintf("unknown character %q (%U)", unit.Str, r)})
+		}
+	}
+	// offsets of the units of words without vowel, already reported
+	vowelless := make(map[int]bool)
+	for start := 0; start < len(Units); {
+		end := start
+		hasVowel := false
+		for end < len(Units) && Units[end].IsRelevant() {
+			hasVowel = hasVowel || contains(VowelTypes, Units[end].Type)
+			end += 1
+		}
+		if end == start {
+			start += 1
+			continue
+		}
+		if !hasVowel {
+			var word string
+			for _, unit := range Units[start:end] {
+				word += unit.Str
+				vowelless[unit.Pos.Offset] = true
+			}
+			diags = append(diags, Diagnostic{Units[start].Pos, Error, fmt.Sprintf("word without vowel %q", word)})
+		}
+		start = end
+	}
+	for _, Paragraph := range doc.Paragraphs {
+		for _, Segment := range Paragraph {
+			for h, Syllable := range Segment {
+				if !Syllable.Relevant || vowelless[Syllable.Pos().Offset] || syllableHasVowel(Syllable) {
+					continue
+				}
+				context := Syllable.String()
+				if h+1 < len(Segment) {
+					context += "⸱" + Segment[h+1].String()
+				}
+				diags = append(diags, Diagnostic{Syllable.Pos(), Warning, fmt.Sprintf("unusual consonant cluster split as %q", strings.TrimSpace(context))})
+			}
+		}
+	}
+	sort.SliceStable(diags, func(i, j int) bool { return diags[i].Pos.Offset < diags[j].Pos.Offset })
+	return
+}
+
+func syllableHasVowel(Syllable SyllableType) bool {
+	for _, unit := range Syllable.Units {
+		if contains(VowelTypes, unit.Type) {
+			return true
+		}
+	}
+	return false
+}
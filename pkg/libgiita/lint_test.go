@@ -0,0 +1,93 @@
+package libgiita
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		src      string
+		cmtMarks string
+		want     []string
+	}{
+		{"namo tassa bhagavato", "", nil},
+		{"namo x tassa", "", []string{`1:6: error: unknown character "x" (U+0078)`}},
+		{"namo 12 tassa", "", nil},
+		{"namo\nm tassa", "", []string{`2:1: error: word without vowel "m"`}},
+		{"x svākkhāto", "", []string{`1:1: error: unknown character "x" (U+0078)`}},
+		{"svākkhāto", "", []string{`1:1: warning: unusual consonant cluster split as "s⸱vāk"`}},
+		{"namo [a [b] tassa", "[:]", []string{`1:9: error: nested comment mark "[" in the comment opened at 1:6`}},
+		{"namo [a] tassa]", "[:]", []string{`1:15: error: comment mark "]" closes no comment`}},
+		{"namo [a tassa", "[:]", []string{`1:6: error: comment is not closed`}},
+		{"namo [it's...] tassa", "[:]", nil},
+		{"namo… tassa...", "", []string{
+			"1:5: warning: ellipsis, the chanting text could be incomplete",
+			"1:12: warning: ellipsis, the chanting text could be incomplete",
+		}},
+		{"buddhaṁ dhammaṁ saṅghaṃ", "", []string{"1:23: warning: ṃ mixed with 2 occurrence(s) of ṁ"}},
+		{"viññūhī'ti", "", []string{"1:8: warning: ASCII apostrophe, elisions are marked with ’"}},
+	}
+	for _, tt := range tests {
+		diags, err := Lint(tt.src, Options{CmtMarks: tt.cmtMarks, Exceptions: DefaultExceptions})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, d := range diags {
+			got = append(got, d.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("Lint(%q):\n got %q\nwant %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestLintGlosses(t *testing.T) {
+	src := "namo tassa\n= don't... [x\nbuddhaṁ x"
+	diags, err := Lint(src, Options{CmtMarks: "[:]", Gloss: "="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range diags {
+		got = append(got, d.String())
+	}
+	// the gloss lines are neither Pali nor comment marks
+	want := []string{`3:9: error: unknown character "x" (U+0078)`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Lint(%q):\n got %q\nwant %q", src, got, want)
+	}
+}
+
+func TestLintPe(t *testing.T) {
+	// the second abbreviation can't be expanded
+	src := "rūpaṃ aniccaṃ ... pe ... vedanā.\nevaṃ … pe … bhikkhave"
+	for _, tt := range []struct {
+		expandPe bool
+		want     []string
+	}{
+		{false, []string{
+			"1:15: warning: ellipsis, the chanting text could be incomplete",
+			"1:22: warning: ellipsis, the chanting text could be incomplete",
+			"2:6: warning: ellipsis, the chanting text could be incomplete",
+			"2:11: warning: ellipsis, the chanting text could be incomplete",
+		}},
+		{true, []string{
+			"2:6: warning: ellipsis, the chanting text could be incomplete",
+			"2:11: warning: ellipsis, the chanting text could be incomplete",
+		}},
+	} {
+		diags, err := Lint(src, Options{ExpandPe: tt.expandPe})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, d := range diags {
+			got = append(got, d.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("Lint with ExpandPe %v:\n got %q\nwant %q", tt.expandPe, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package libgiita
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// referenceParser is the former implementation of Parser, based on lists of
+// strings then regular expressions, against which the trie is checked.
+func referenceParser(src string) (RawUnits []UnitType) {
+	compile := func(list []string) (res []*regexp.Regexp) {
+		for _, s := range list {
+			res = append(res, regexp.MustCompile("(?i)^"+s))
+		}
+		return
+	}
+	f := func(m string, src *string, i int) (u UnitType) {
+		u = UnitType{Str: m, Type: i}
+		*src = strings.TrimPrefix(*src, m)
+		return
+	}
+	Lists := [][]string{LongVwls, ShortVwls, C, FrequentElisionMark, FrequentPunc, FrequentSpace, FrequentOther}
+	reLists := [][]*regexp.Regexp{compile(LongVwls), compile(ShortVwls), compile(C), nil, {RePunc}, {ReSpace}}
+Outerloop:
+	for src != "" {
+		for i, list := range Lists {
+			for _, s := range list {
+				if strings.HasPrefix(src, s) {
+					RawUnits = append(RawUnits, f(s, &src, i))
+					continue Outerloop
+				}
+			}
+		}
+		for i, list := range reLists {
+			for _, re := range list {
+				if re.MatchString(src) {
+					RawUnits = append(RawUnits, f(re.FindString(src), &src, i))
+					continue Outerloop
+				}
+			}
+		}
+		_, size := utf8.DecodeRuneInString(src)
+		RawUnits = append(RawUnits, f(src[:size], &src, Other))
+	}
+	return
+}
+
+var parserTests = []string{
+	"Namo tassa bhagavato arahato sammāsambuddhassa.",
+	"BHAGAVĀ Sammā THĀNA Ṭhāna ṬHĀNA Smiṁ SMIṀ Kelvin",
+	"ſati Kaya",
+	"viññūhī’ti! — «so» (ca)... ?!;",
+	"a\r\n\r\nb\t \tc\fd e",
+	"123 x ẞ ß ø 𓃰 𐂂",
+	"\xa2\xff\x80 namo",
+}
+
+func TestParserReference(t *testing.T) {
+	srcs := parserTests
+	corpus, _ := filepath.Glob(filepath.Join("testdata", "corpus", "*.txt"))
+	for _, path := range corpus {
+		dat, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srcs = append(srcs, string(dat))
+	}
+	for _, src := range srcs {
+		if got, want := units(Parser(src)), units(referenceParser(src)); got != want {
+			t.Errorf("Parser(%q):\n got %s\nwant %s", src, got, want)
+		}
+	}
+}
+
+func benchmarkParser(b *testing.B, parser func(string) []UnitType) {
+	var src strings.Builder
+	corpus, _ := filepath.Glob(filepath.Join("testdata", "corpus", "*.txt"))
+	for _, path := range corpus {
+		dat, err := os.ReadFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		src.Write(dat)
+	}
+	b.SetBytes(int64(src.Len()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parser(src.String())
+	}
+}
+
+func BenchmarkParser(b *testing.B) {
+	benchmarkParser(b, Parser)
+}
+
+func BenchmarkReferenceParser(b *testing.B) {
+	benchmarkParser(b, referenceParser)
+}
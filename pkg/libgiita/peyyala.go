@@ -0,0 +1,170 @@
+package libgiita
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Formulas are stock passages referenced by name in a text, to expand the
+// abbreviations (peyyāla) of the repeated formulas.
+//
+// In a formulas file, each line holds a name and its text separated by "=",
+// "#" starts a comment and an indented line continues the text of the
+// previous formula on a new line, e.g.
+//
+//	namo = namo tassa bhagavato arahato sammāsambuddhassa
+//	saranagamana = buddhaṃ saraṇaṃ gacchāmi
+//	    dhammaṃ saraṇaṃ gacchāmi
+//	    saṅghaṃ saraṇaṃ gacchāmi
+//
+// The text refers to them with {name}, optionally with substitutions
+// separated by commas: {saranagamana: gacchāmi = gacchāma}. Formulas can
+// refer to other formulas.
+type Formulas map[string]string
+
+const maxFormulaDepth = 10
+
+var (
+	reFormulaRef = regexp.MustCompile(`\{([\pL\pN_-]+)(?:\s*:([^}]*))?\}`)
+	// "... pe ...", also written "…pa…" in some editions
+	rePe = regexp.MustCompile(`[ \t]*(?:\.\.\.|…)[ \t]*p[ae][ \t]*(?:\.\.\.|…)[ \t]*`)
+	// punctuation and linebreaks delimiting the phrases around "... pe ..."
+	rePhraseEnd = regexp.MustCompile(`[.,;:!?\n]`)
+)
+
+// ParseFormulas reads a formulas file, see Formulas for the format.
+func ParseFormulas(r io.Reader) (f Formulas, err error) {
+	f = make(Formulas)
+	scanner := bufio.NewScanner(r)
+	n, last := 0, ""
+	for scanner.Scan() {
+		n += 1
+		raw, _, _ := strings.Cut(scanner.Text(), "#")
+		line := strings.TrimSpace(raw)
+		switch {
+		case line == "":
+			continue
+		case raw[0] == ' ' || raw[0] == '\t':
+			if last == "" {
+				return nil, fmt.Errorf("line %d: indented line without formula", n)
+			}
+			f[last] += "\n" + line
+			continue
+		}
+		name, text, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: missing \"=\" between name and text", n)
+		}
+		name = strings.TrimSpace(name)
+		if !reFormulaRef.MatchString("{" + name + "}") {
+			return nil, fmt.Errorf("line %d: invalid formula name %q", n, name)
+		}
+		f[name], last = strings.TrimSpace(text), name
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// expand returns the text of the formula referenced by ref e.g.
+// "{name: old = new}", after substitutions.
+func (f Formulas) expand(ref string, depth int) (string, error) {
+	sub := reFormulaRef.FindStringSubmatch(ref)
+	text, ok := f[sub[1]]
+	if !ok {
+		return "", fmt.Errorf("unknown formula %q", sub[1])
+	}
+	if depth > maxFormulaDepth {
+		return "", fmt.Errorf("formula %q: too many nested formulas", sub[1])
+	}
+	if strings.TrimSpace(sub[2]) != "" {
+		// the substitutions are simultaneous so that words can be swapped
+		var oldnew []string
+		for _, pair := range strings.Split(sub[2], ",") {
+			old, repl, found := strings.Cut(pair, "=")
+			if old = strings.TrimSpace(old); !found || old == "" {
+				return "", fmt.Errorf("formula %q: invalid substitution %q, expected \"old = new\"", sub[1], pair)
+			}
+			oldnew = append(oldnew, old, strings.TrimSpace(repl))
+		}
+		text = strings.NewReplacer(oldnew...).Replace(text)
+	}
+	var err error
+	text = reFormulaRef.ReplaceAllStringFunc(text, func(ref string) string {
+		var s string
+		if err == nil {
+			s, err = f.expand(ref, depth+1)
+		}
+		return s
+	})
+	return text, err
+}
+
+// expandFormulas replaces the references to formulas by their text. The
+// offsets of the expansions, which all map to the beginning of the
+// reference, are added to expanded.
+func (m offsetMap) expandFormulas(src string, f Formulas, expanded map[int]bool) (string, offsetMap, error) {
+	ranges := reFormulaRef.FindAllStringIndex(src, -1)
+	repls := make([]string, len(ranges))
+	for i, r := range ranges {
+		var err error
+		if repls[i], err = f.expand(src[r[0]:r[1]], 0); err != nil {
+			return src, m, err
+		}
+		expanded[m[r[0]]] = true
+	}
+	src, m = m.replaceFunc(src, ranges, func(i int) string { return repls[i] })
+	return src, m, nil
+}
+
+// expandPe expands "A B C D ... pe ... X." into "A B C D, X B C D.": the
+// words following the abbreviation replace as many words at the beginning of
+// the phrase before it. The abbreviation is kept if there are as many words
+// after it as before it, since the omitted part can't be known. The offsets
+// of the words added, which map to the end of the phrase, are added to expanded.
+// The source ranges of the abbreviations expanded are returned as well.
+func (m offsetMap) expandPe(src string, expanded map[int]bool) (string, offsetMap, [][]int) {
+	var ranges, abbrevs [][]int
+	var repls []string
+	for _, loc := range rePe.FindAllStringIndex(src, -1) {
+		before := strings.TrimRight(src[:loc[0]], " \t.,;:!?")
+		phrase := before
+		if i := lastIndex(rePhraseEnd, before); i >= 0 {
+			phrase = before[i+1:]
+		}
+		after := src[loc[1]:]
+		end := len(src)
+		if i := rePhraseEnd.FindStringIndex(after); i != nil {
+			end = loc[1] + i[0]
+		}
+		words, substitutes := strings.Fields(phrase), strings.Fields(src[loc[1]:end])
+		if len(substitutes) == 0 || len(substitutes) >= len(words) ||
+			len(ranges) > 0 && loc[0] < ranges[len(ranges)-1][1] {
+			continue
+		}
+		// the phrase may already end with a punctuation mark
+		sep := " "
+		if len(before) == loc[0] {
+			sep = ", "
+		}
+		ranges = append(ranges, []int{loc[0], loc[1]}, []int{end, end})
+		repls = append(repls, sep, " "+strings.Join(words[len(substitutes):], " "))
+		expanded[m[end]] = true
+		abbrevs = append(abbrevs, []int{m[loc[0]], m[loc[1]]})
+	}
+	src, m = m.replaceFunc(src, ranges, func(i int) string { return repls[i] })
+	return src, m, abbrevs
+}
+
+// lastIndex returns the index of the last match of re in s, -1 if none
+func lastIndex(re *regexp.Regexp, s string) int {
+	locs := re.FindAllStringIndex(s, -1)
+	if len(locs) == 0 {
+		return -1
+	}
+	return locs[len(locs)-1][0]
+}
@@ -0,0 +1,114 @@
+package libgiita
+
+import (
+	"strings"
+	"testing"
+)
+
+const testFormulas = `# test formulas
+namo = namo tassa bhagavato arahato sammāsambuddhassa
+saranagamana = buddhaṃ saraṇaṃ gacchāmi
+    dhammaṃ saraṇaṃ gacchāmi
+    saṅghaṃ saraṇaṃ gacchāmi
+vandana = {namo}, {saranagamana: gacchāmi = gacchāma}
+loop = {loop}
+`
+
+func TestParseFormulas(t *testing.T) {
+	f, err := ParseFormulas(strings.NewReader(testFormulas))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f) != 4 || f["saranagamana"] != "buddhaṃ saraṇaṃ gacchāmi\ndhammaṃ saraṇaṃ gacchāmi\nsaṅghaṃ saraṇaṃ gacchāmi" {
+		t.Errorf("ParseFormulas = %q", f)
+	}
+	for _, src := range []string{"  indented = first", "no separator", "bad name = text"} {
+		if _, err := ParseFormulas(strings.NewReader(src)); err == nil {
+			t.Errorf("ParseFormulas(%q): no error", src)
+		}
+	}
+}
+
+func TestExpandFormulas(t *testing.T) {
+	f, err := ParseFormulas(strings.NewReader(testFormulas))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		src, want string
+	}{
+		{"{namo}.", "namo tassa bhagavato arahato sammāsambuddhassa."},
+		{"{saranagamana: buddhaṃ = dhammaṃ, dhammaṃ = buddhaṃ}",
+			"dhammaṃ saraṇaṃ gacchāmi\nbuddhaṃ saraṇaṃ gacchāmi\nsaṅghaṃ saraṇaṃ gacchāmi"},
+		{"{vandana}", "namo tassa bhagavato arahato sammāsambuddhassa, " +
+			"buddhaṃ saraṇaṃ gacchāma\ndhammaṃ saraṇaṃ gacchāma\nsaṅghaṃ saraṇaṃ gacchāma"},
+	}
+	for _, test := range tests {
+		got, _, err := newOffsetMap(test.src).expandFormulas(test.src, f, map[int]bool{})
+		if err != nil {
+			t.Errorf("%q: %v", test.src, err)
+		} else if got != test.want {
+			t.Errorf("%q: got %q, want %q", test.src, got, test.want)
+		}
+	}
+	for _, src := range []string{"{unknown}", "{loop}", "{namo: tassa}"} {
+		if _, _, err := newOffsetMap(src).expandFormulas(src, f, map[int]bool{}); err == nil {
+			t.Errorf("%q: no error", src)
+		}
+	}
+}
+
+func TestExpandPe(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"dutiyampi buddhaṃ saraṇaṃ gacchāmi. ... pe ... tatiyampi.",
+			"dutiyampi buddhaṃ saraṇaṃ gacchāmi. tatiyampi buddhaṃ saraṇaṃ gacchāmi."},
+		{"rūpaṃ aniccaṃ …pe… viññāṇaṃ\n",
+			"rūpaṃ aniccaṃ, viññāṇaṃ aniccaṃ\n"},
+		// nothing to repeat
+		{"evaṃ ... pe ... bhikkhave", "evaṃ ... pe ... bhikkhave"},
+		{"... pe ...", "... pe ..."},
+	}
+	for _, test := range tests {
+		got, _, _ := newOffsetMap(test.src).expandPe(test.src, map[int]bool{})
+		if got != test.want {
+			t.Errorf("%q: got %q, want %q", test.src, got, test.want)
+		}
+	}
+}
+
+func TestExpanded(t *testing.T) {
+	f, err := ParseFormulas(strings.NewReader(testFormulas))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := Process("sādhu {namo}\nrūpaṃ aniccaṃ ... pe ... vedanā.", Options{Formulas: f, ExpandPe: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expanded, kept []string
+	for _, Paragraph := range doc.Paragraphs {
+		for _, Segment := range Paragraph {
+			for _, Syllable := range Segment {
+				if !Syllable.Relevant {
+					continue
+				}
+				if Syllable.Expanded {
+					expanded = append(expanded, Syllable.String())
+				} else {
+					kept = append(kept, Syllable.String())
+				}
+			}
+		}
+	}
+	if got, want := strings.Join(expanded, " "), "na mo tas sa bha ga va to a ra ha to sam mā sam bud dhas sa a nic caṁ"; got != want {
+		t.Errorf("expanded = %q, want %q", got, want)
+	}
+	if got, want := strings.Join(kept, " "), "sā dhu rū paṁ a nic caṁ ve da nā"; got != want {
+		t.Errorf("kept = %q, want %q", got, want)
+	}
+	if _, err = Process("{unknown}", Options{Formulas: f}); err == nil {
+		t.Error("unknown formula: no error")
+	}
+}
@@ -0,0 +1,168 @@
+package libgiita
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pos is a position in the source text.
+type Pos struct {
+	// Offset is the byte offset, from 0
+	Offset int
+	// Line and Col start at 1, Col counts runes. A zero Line means the position is unknown.
+	Line, Col int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
+// advance returns the position following s
+func (p Pos) advance(s string) Pos {
+	p.Offset += len(s)
+	for _, r := range s {
+		if r == '\n' {
+			p.Line, p.Col = p.Line+1, 1
+		} else {
+			p.Col += 1
+		}
+	}
+	return p
+}
+
+// Pos returns the position of the first unit of the syllable.
+func (Syllable *SyllableType) Pos() Pos {
+	if len(Syllable.Units) == 0 {
+		return Pos{}
+	}
+	return Syllable.Units[0].Pos
+}
+
+// Pos returns the position of the first syllable of the segment.
+func (Segment *SegmentType) Pos() Pos {
+	for _, Syllable := range *Segment {
+		if len(Syllable.Units) > 0 {
+			return Syllable.Pos()
+		}
+	}
+	return Pos{}
+}
+
+// Pos returns the position of the first segment of the paragraph.
+func (Paragraph *ParagraphType) Pos() Pos {
+	for _, Segment := range *Paragraph {
+		if p := Segment.Pos(); p.Line != 0 {
+			return p
+		}
+	}
+	return Pos{}
+}
+
+// offsetMap maps each byte offset of a rewritten text, and its end, to the
+// corresponding offset in the source.
+type offsetMap []int
+
+func newOffsetMap(src string) offsetMap {
+	m := make(offsetMap, len(src)+1)
+	for i := range m {
+		m[i] = i
+	}
+	return m
+}
+
+// replace replaces the given [start, end) ranges of src with repl, whose bytes
+// are mapped to the start of the range they replace.
+func (m offsetMap) replace(src string, ranges [][]int, repl string) (string, offsetMap) {
+	return m.replaceFunc(src, ranges, func(int) string { return repl })
+}
+
+// replaceFunc replaces the i-th range with repl(i)
+func (m offsetMap) replaceFunc(src string, ranges [][]int, repl func(i int) string) (string, offsetMap) {
+	if len(ranges) == 0 {
+		return src, m
+	}
+	var b strings.Builder
+	n := make(offsetMap, 0, len(m))
+	last := 0
+	for i, r := range ranges {
+		repl := repl(i)
+		b.WriteString(src[last:r[0]])
+		n = append(n, m[last:r[0]]...)
+		b.WriteString(repl)
+		for j := 0; j < len(repl); j++ {
+			n = append(n, m[r[0]])
+		}
+		last = r[1]
+	}
+	b.WriteString(src[last:])
+	n = append(n, m[last:]...)
+	return b.String(), n
+}
+
+// replaceRe is ReplaceAllString with a literal replacement, keeping track of the offsets
+func (m offsetMap) replaceRe(src string, re *regexp.Regexp, repl string) (string, offsetMap) {
+	return m.replace(src, re.FindAllStringIndex(src, -1), repl)
+}
+
+// replaceString is strings.ReplaceAll keeping track of the offsets
+func (m offsetMap) replaceString(src, old, repl string) (string, offsetMap) {
+	var ranges [][]int
+	for i := 0; ; {
+		j := strings.Index(src[i:], old)
+		if j < 0 {
+			break
+		}
+		ranges = append(ranges, []int{i + j, i + j + len(old)})
+		i += j + len(old)
+	}
+	return m.replace(src, ranges, repl)
+}
+
+// transliterated maps dst, the transliteration of src, back to the source.
+// Transliteration works character by character so offsets are only kept on the
+// lines left unchanged, the others are mapped to their beginning.
+func (m offsetMap) transliterated(src, dst string) offsetMap {
+	if src == dst {
+		return m
+	}
+	srcLines, dstLines := strings.SplitAfter(src, "\n"), strings.SplitAfter(dst, "\n")
+	if len(srcLines) != len(dstLines) {
+		n := make(offsetMap, len(dst)+1)
+		for i := range n {
+			n[i] = m[0]
+		}
+		return n
+	}
+	n := make(offsetMap, 0, len(dst)+1)
+	srcStart := 0
+	for i, line := range dstLines {
+		if line == srcLines[i] {
+			n = append(n, m[srcStart:srcStart+len(line)]...)
+		} else {
+			for j := 0; j < len(line); j++ {
+				n = append(n, m[srcStart])
+			}
+		}
+		srcStart += len(srcLines[i])
+	}
+	return append(n, m[len(src)])
+}
+
+// positioner computes the positions of increasing offsets of src in a single pass
+type positioner struct {
+	src  string
+	last Pos
+}
+
+func newPositioner(src string) *positioner {
+	return &positioner{src: src, last: Pos{Line: 1, Col: 1}}
+}
+
+func (p *positioner) pos(offset int) Pos {
+	if offset < p.last.Offset {
+		p.last = Pos{Line: 1, Col: 1}
+	}
+	p.last = p.last.advance(p.src[p.last.Offset:offset])
+	return p.last
+}
@@ -0,0 +1,60 @@
+package libgiita
+
+import "testing"
+
+func TestPositions(t *testing.T) {
+	tests := []struct {
+		src  string
+		opts Options
+		// text of a syllable and its expected position
+		syllable string
+		want     Pos
+	}{
+		{"namo tassa", Options{}, "tas", Pos{5, 1, 6}},
+		{"namo\ntassa", Options{}, "sa", Pos{8, 2, 4}},
+		{"ā ī\nū", Options{}, "ū", Pos{6, 2, 1}},
+		{"[comment] namo\n[para]\ntassa", Options{CmtMarks: "[:]"}, "mo", Pos{12, 1, 13}},
+		{"[comment] namo\n[para]\ntassa", Options{CmtMarks: "[:]"}, "tas", Pos{22, 3, 1}},
+		{"kāya-gatā", Options{}, "ga", Pos{6, 1, 6}},
+		{"123 namo tassa", Options{Re: `\d+ `}, "tas", Pos{9, 1, 10}},
+		{"arahaṃ tassa", Options{}, "tas", Pos{9, 1, 8}},
+		{"นโม\ntassa", Options{ThaiTranslit: 1}, "tas", Pos{10, 2, 1}},
+		{"नमो तस्स", Options{Script: Devanagari}, "tas", Pos{10, 1, 5}},
+	}
+	for _, tt := range tests {
+		doc, err := Process(tt.src, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, Paragraph := range doc.Paragraphs {
+			for _, Segment := range Paragraph {
+				for _, Syllable := range Segment {
+					if Syllable.String() == tt.syllable && !found {
+						found = true
+						if got := Syllable.Pos(); got != tt.want {
+							t.Errorf("%q: position of %q = %d (%s), want %d (%s)", tt.src, tt.syllable, got.Offset, got, tt.want.Offset, tt.want)
+						}
+					}
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%q: syllable %q not found", tt.src, tt.syllable)
+		}
+	}
+}
+
+func TestOffsetMap(t *testing.T) {
+	src := "a-b--c"
+	dst, m := newOffsetMap(src).replaceString(src, "-", "")
+	if dst != "abc" {
+		t.Fatalf("replaceString = %q", dst)
+	}
+	want := []int{0, 2, 5, 6}
+	for i, offset := range want {
+		if m[i] != offset {
+			t.Errorf("m[%d] = %d, want %d", i, m[i], offset)
+		}
+	}
+}
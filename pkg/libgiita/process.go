@@ -0,0 +1,308 @@
+package libgiita
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	pli "github.com/tassa-yoniso-manasi-karoto/pali-transliteration"
+)
+
+var (
+	CmtParaMark = "𐂂"
+	CmtSpanMark = "𓃰"
+	GlossMark   = "𐂃"
+
+	ErrCmtMarks  = errors.New("invalid comment marks: expected two characters separated by a colon e.g. \"[:]\"")
+	ErrInvalidRe = errors.New("invalid regular expression")
+)
+
+// Options holds everything that affects the analysis of a text, independently
+// of the way it is rendered afterwards.
+type Options struct {
+	// Hint sets the sensitivity of breath hints as to what counts as a list,
+	// reasonable range between 4 and 6, 0 disables hints.
+	Hint float64
+	// CmtMarks are the characters marking respectively the beginning and
+	// the end of a comment, separated by a colon e.g. "[:]". Empty disables comments.
+	CmtMarks string
+	// Gloss is the prefix of the lines holding a companion text e.g. "=" for
+	// "= Homage to him". Empty disables glosses.
+	Gloss string
+	// Re is a regular expression (RE2 syntax) whose matches are deleted from the source.
+	Re string
+	// ThaiTranslit transliterates from Thai script, see pli.ThaiToRoman. 0 disables it.
+	ThaiTranslit int
+	// Script is transliterated to IAST, see ScriptNames. Roman disables it.
+	Script int
+	// Scheme is the romanization of the source, see SchemeNames. Comments are
+	// never converted.
+	Scheme int
+	// Formulas are expanded where the text refers to them, see Formulas.
+	Formulas Formulas
+	// Lexicon is used to split the compound words into their members, so that
+	// hints are preferably placed at the end of a member. nil disables it.
+	Lexicon Lexicon
+	// ExpandPe expands the abbreviations "... pe ..." by repeating the phrase before them.
+	ExpandPe bool
+	// OptionalLow enables the detection of the optional low tone
+	OptionalLow bool
+	// HintConfig tunes the placement of the hints, nil uses DefaultHintConfig.
+	HintConfig *HintConfig
+	// Exceptions override the rules of syllabification and tones for the words
+	// they match, see DefaultExceptions for the ones known to be needed.
+	Exceptions Exceptions
+	Debug      DebugType
+}
+
+type DebugType struct {
+	Hint, Rate, List, Stats bool
+	// Report explains the placement of the hints in each segment
+	Report bool
+	// Color enables ANSI colors in the debug output
+	Color bool
+	// W receives the debug output, defaults to os.Stderr
+	W io.Writer
+}
+
+// Document is the result of the analysis of a source text.
+type Document struct {
+	Paragraphs []ParagraphType
+	// comments extracted from the source, in order of appearance. Their position
+	// in the text is held by CmtParaMark and CmtSpanMark respectively.
+	CmtsPara, CmtsSpan []string
+	// companion texts, in order of appearance. Each one follows the lines of
+	// the source it belongs to and its position is held by GlossMark.
+	Glosses []string
+	// number of occurences of "..." or "…", which usually indicates an ellipsis of a repeated formula
+	Ellipses int
+	// number of segments in which at least one hint was added
+	HintedSegments int
+	// source ranges of the "... pe ..." abbreviations expanded, see Options.ExpandPe
+	pe [][]int
+}
+
+// Process runs the whole pipeline on src: comment extraction, parsing,
+// syllabification, tone marking, segmentation, hinting and paragraph grouping.
+func Process(src string, opts Options) (doc Document, err error) {
+	// the text is rewritten before parsing, m keeps track of the original offsets
+	orig, m := src, newOffsetMap(src)
+	if opts.Re != "" {
+		re, err := regexp.Compile(opts.Re)
+		if err != nil {
+			return doc, fmt.Errorf("%w: %w", ErrInvalidRe, err)
+		}
+		src, m = m.replaceRe(src, re, "")
+	}
+	if opts.Gloss != "" {
+		src, m, doc.Glosses = extractGlosses(src, m, opts.Gloss)
+	}
+	if opts.CmtMarks != "" {
+		if len(opts.CmtMarks) != 3 {
+			return doc, ErrCmtMarks
+		}
+		src, m, doc.CmtsPara, doc.CmtsSpan = extractCmts(src, m, opts.CmtMarks)
+	}
+	// before any transliteration, the scheme is detected on the roman text only
+	src, m = m.toIAST(src, opts.Scheme)
+	roman := pli.ThaiToRoman(src, opts.ThaiTranslit)
+	src, m = roman, m.transliterated(src, roman)
+	src, m = m.fromScript(src, opts.Script)
+	// source offsets of the expansions
+	expanded := make(map[int]bool)
+	if opts.Formulas != nil {
+		if src, m, err = m.expandFormulas(src, opts.Formulas, expanded); err != nil {
+			return
+		}
+	}
+	if opts.ExpandPe {
+		src, m, doc.pe = m.expandPe(src, expanded)
+	}
+	// same length in bytes, offsets are unchanged
+	src = strings.ReplaceAll(src, "ṃ", "ṁ")
+	src = strings.ReplaceAll(src, "Ṃ", "Ṁ")
+	// chunks from long compound words need to be reunited or will be treated as separate
+	src, m = m.replaceString(src, "-", "")
+	// those of the expanded abbreviations are gone
+	doc.Ellipses = strings.Count(src, "...") + strings.Count(src, "…")
+	Units := Parser(src)
+	p := newPositioner(orig)
+	for i := range Units {
+		Units[i].Pos = p.pos(m[Units[i].Pos.Offset])
+	}
+	opts.Exceptions.Apply(Units)
+	Syllables := SetTones(SyllableBuilder(Units))
+	for i, Syllable := range Syllables {
+		Syllables[i].Expanded = Syllable.Relevant && expanded[Syllable.Pos().Offset]
+	}
+	if opts.Lexicon != nil {
+		opts.Lexicon.markMembers(Syllables)
+	}
+	if opts.OptionalLow {
+		Syllables = SetOptionalLow(Syllables)
+	}
+	applyTones(Syllables, opts.OptionalLow)
+	Segments := SegmentBuilder(Syllables)
+	if opts.Hint != 0 {
+		if opts.HintConfig != nil {
+			if err = opts.HintConfig.Validate(); err != nil {
+				return doc, fmt.Errorf("invalid hint configuration: %w", err)
+			}
+		}
+		if opts.Debug.Report {
+			fmt.Fprintf(opts.Debug.out(), "[report] hint configuration: %s\n", opts.hintConfig())
+		}
+		for i, Segment := range Segments {
+			Segments[i] = MakeHint(Segment, &doc.HintedSegments, opts)
+		}
+		if opts.Debug.Hint || opts.Debug.Stats {
+			fmt.Fprintf(opts.Debug.out(), "[hint] added hint(s) in %.1f%% of all segments (%d/%d)\n",
+				float64(doc.HintedSegments)/float64(len(Segments))*100, doc.HintedSegments, len(Segments))
+		}
+	}
+	doc.Paragraphs = ParagraphBuilder(Segments)
+	return
+}
+
+func extractCmts(src string, m offsetMap, marks string) (string, offsetMap, []string, []string) {
+	open, close := regexp.QuoteMeta(marks[0:1]), regexp.QuoteMeta(marks[2:3])
+	reCmtSpan := regexp.MustCompile(fmt.Sprintf(`(?s)%s.*?%s`, open, close))
+	// newline "\n" included won't be replaced as a <br>, accordingly \n{0,2} makes up for the newline added by the <p> tag
+	reCmtPara := regexp.MustCompile(fmt.Sprintf(`(?sm)^ *%s[^%s]*?%s *\n{0,2}`, open, close, close))
+	cmtsPara := reCmtPara.FindAllString(src, -1)
+	for i, CmtPara := range cmtsPara {
+		cmtsPara[i], _ = strings.CutPrefix(CmtPara, "\n")
+	}
+	src, m = m.replaceRe(src, reCmtPara, CmtParaMark)
+	cmtsSpan := reCmtSpan.FindAllString(src, -1)
+	src, m = m.replaceRe(src, reCmtSpan, CmtSpanMark)
+	return src, m, cmtsPara, cmtsSpan
+}
+
+// extractGlosses replaces each group of consecutive lines beginning with
+// prefix, along with the newline before them, by a GlossMark so that the
+// mark directly follows the lines of the source they belong to.
+func extractGlosses(src string, m offsetMap, prefix string) (string, offsetMap, []string) {
+	ranges, glosses := findGlosses(src, prefix)
+	src, m = m.replace(src, ranges, GlossMark)
+	return src, m, glosses
+}
+
+// findGlosses returns the ranges of src removed by extractGlosses and the glosses they hold.
+func findGlosses(src, prefix string) (ranges [][]int, glosses []string) {
+	reGloss := regexp.MustCompile(fmt.Sprintf(`(?:\A|\n)[ \t]*%s[ \t]?([^\n]*)`, regexp.QuoteMeta(prefix)))
+	for _, loc := range reGloss.FindAllStringSubmatchIndex(src, -1) {
+		line := strings.TrimSpace(src[loc[2]:loc[3]])
+		if n := len(ranges); n > 0 && ranges[n-1][1] == loc[0] {
+			ranges[n-1][1] = loc[1]
+			glosses[n-1] += "\n" + line
+			continue
+		}
+		ranges = append(ranges, []int{loc[0], loc[1]})
+		glosses = append(glosses, line)
+	}
+	return
+}
+
+func SetTones(Syllables []SyllableType) []SyllableType {
+	for h, Syllable := range Syllables {
+		for i, unit := range Syllable.Units {
+			var NextUnit UnitType
+			firstUnit := strings.ToLower(Syllable.Units[0].Str)
+			if len(Syllable.Units) > i+1 {
+				NextUnit = Syllable.Units[i+1]
+			}
+			Syllable.Irrelevant = !unit.IsRelevant() // FIXME: syl have many units
+			if (unit.Type == ShortVwl && strings.ToLower(NextUnit.Str) == "ṁ") ||
+				(unit.Type == ShortVwl && NextUnit.Type == Cons && NextUnit.Closing) ||
+				(unit.Type == LongVwl) {
+				Syllable.IsLong = true
+			}
+			if contains(UnstopChar, strings.ToLower(unit.Str)) && unit.Closing ||
+				(unit.Type == LongVwl && unit.Closing) {
+				Syllable.NotStopped = true
+			}
+			if contains(HighToneFirstChar, firstUnit) {
+				Syllable.HasHighToneFirstChar = true
+			}
+			if Syllable.HasHighToneFirstChar && Syllable.NotStopped && Syllable.IsLong {
+				Syllable.TrueHigh = true
+			}
+			//---
+			if !Syllable.TrueHigh && unit.Type == ShortVwl && contains(OptHighFirstChar, firstUnit) {
+				if unit.Closing || !contains(UnstopChar, strings.ToLower(NextUnit.Str)) {
+					Syllable.OptionalHigh = true
+				}
+			}
+			Syllables[h] = Syllable
+		}
+	}
+	return Syllables
+}
+
+// SetOptionalLow marks the stopped syllables beginning with a vowel or with a
+// consonant of the Thai middle or high class, which can be chanted with a low
+// tone. It requires the tones to be set beforehand. The words escaping these
+// rules are marked by the "," tone of the exceptions, see DefaultExceptionsSrc.
+func SetOptionalLow(Syllables []SyllableType) []SyllableType {
+	for h, Syllable := range Syllables {
+		if Syllable.Irrelevant || Syllable.TrueHigh || Syllable.OptionalHigh || Syllable.NotStopped {
+			continue
+		}
+		firstUnit := Syllable.Units[0]
+		firstChar := strings.ToLower(firstUnit.Str)
+		if contains(VowelTypes, firstUnit.Type) || contains(OptLowFirstChar, firstChar) ||
+			contains(HighToneFirstChar, firstChar) {
+			Syllables[h].OptionalLow = true
+		}
+	}
+	return Syllables
+}
+
+func ParagraphBuilder(Segments []SegmentType) (Paragraphs []ParagraphType) {
+	var Paragraph ParagraphType
+	for i, Segment := range Segments {
+		Paragraph = append(Paragraph, Segment)
+		if IsClosingPara(&Segment) || i == len(Segments)-1 {
+			Paragraphs = append(Paragraphs, Paragraph)
+			Paragraph = *new(ParagraphType)
+		}
+	}
+	return
+}
+
+// IsClosingPara reports whether the segment ends a paragraph and marks the
+// syllable holding the blank line as ClosingPara.
+func IsClosingPara(Segment *SegmentType) bool {
+	for i, Syllable := range *Segment {
+		if strings.Contains(Syllable.String(), "\n\n") || strings.Contains(Syllable.String(), "\n"+CmtParaMark) {
+			(*Segment)[i].ClosingPara = true
+			return true
+		}
+	}
+	return false
+}
+
+func (d DebugType) out() io.Writer {
+	if d.W == nil {
+		return os.Stderr
+	}
+	return d.W
+}
+
+func (d DebugType) color(s string) string {
+	if !d.Color {
+		return ""
+	}
+	return s
+}
+
+func (opts Options) hintConfig() HintConfig {
+	if opts.HintConfig != nil {
+		return *opts.HintConfig
+	}
+	return DefaultHintConfig
+}
@@ -0,0 +1,133 @@
+package libgiita
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Renderer writes an analyzed Document in a given output format.
+type Renderer interface {
+	RenderDocument(w io.Writer, doc Document) error
+}
+
+// OptionalLowMark follows the syllables with an optional low tone in the raw text output.
+var OptionalLowMark = "↓"
+
+// TextRenderer outputs raw text where syllables are separated by "⸱" and
+// punctuation is followed by "█".
+type TextRenderer struct {
+	// Newlines is the number of linebreaks created from a single linebreak of the source
+	Newlines int
+	// OptionalHigh formats optional high tones with capital letters
+	OptionalHigh bool
+	// Thai writes the syllables in Thai script, see ThaiSpelling. 0 keeps them in roman script.
+	Thai int
+	// Split writes CompoundSeparator between the members of the compound words, see Options.Lexicon
+	Split bool
+}
+
+func (r TextRenderer) RenderDocument(w io.Writer, doc Document) error {
+	bw := bufio.NewWriter(w)
+	newline := strings.Repeat("\n", r.Newlines)
+	cmts := newCmtIterator(doc)
+	for _, Paragraph := range doc.Paragraphs {
+		for _, Segment := range Paragraph {
+			for h, Syllable := range Segment {
+				spelled := false
+				for _, unit := range Syllable.Units {
+					switch {
+					case strings.Contains(unit.Str, "\n"):
+						bw.WriteString(strings.ReplaceAll(unit.Str, "\n", newline))
+					case ReSpace.MatchString(unit.Str):
+						bw.WriteString(" ")
+					case RePunc.MatchString(unit.Str) && !ReIsExceptPunct.MatchString(unit.Str):
+						bw.WriteString(unit.Str + "█")
+					case cmts.isMark(unit.Str) && unit.Str == GlossMark:
+						// back on its own line as in the source
+						bw.WriteString("\n" + cmts.next(unit.Str))
+					case cmts.isMark(unit.Str):
+						bw.WriteString(cmts.next(unit.Str))
+					case r.Thai != 0 && unit.isLetter():
+						if !spelled {
+							bw.WriteString(Syllable.ThaiSpelling(r.Thai))
+							spelled = true
+						}
+					case r.OptionalHigh && Syllable.OptionalHigh:
+						bw.WriteString(strings.ToUpper(unit.Str))
+					default:
+						bw.WriteString(unit.Str)
+					}
+				}
+				if Syllable.OptionalLow {
+					bw.WriteString(OptionalLowMark)
+				}
+				if r.Split && Syllable.endsMember() {
+					bw.WriteString(CompoundSeparator)
+				} else if Segment.NeedsSeparator(h) {
+					bw.WriteString("⸱")
+				}
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// NeedsSeparator reports whether a syllable separator must be written
+// between the h-th syllable and the next one.
+func (Segment SegmentType) NeedsSeparator(h int) bool {
+	if h >= len(Segment)-1 {
+		return false
+	}
+	lastUnit := Segment[h].Units[len(Segment[h].Units)-1]
+	NextSylFirstUnit := Segment[h+1].Units[0]
+	return lastUnit.IsRelevant() && NextSylFirstUnit.IsRelevant()
+}
+
+// cmtIterator hands out the comments and the glosses of a document in order
+// of appearance as their marks are encountered by a renderer.
+type cmtIterator struct {
+	para, span, glosses []string
+}
+
+func newCmtIterator(doc Document) *cmtIterator {
+	return &cmtIterator{doc.CmtsPara, doc.CmtsSpan, doc.Glosses}
+}
+
+func (c *cmtIterator) isMark(s string) bool {
+	return s == CmtParaMark && len(c.para) > 0 || s == CmtSpanMark && len(c.span) > 0 ||
+		s == GlossMark && len(c.glosses) > 0
+}
+
+func (c *cmtIterator) next(mark string) (cmt string) {
+	switch mark {
+	case CmtParaMark:
+		cmt, c.para = c.para[0], c.para[1:]
+	case CmtSpanMark:
+		cmt, c.span = c.span[0], c.span[1:]
+	default:
+		cmt, c.glosses = c.glosses[0], c.glosses[1:]
+	}
+	return
+}
+
+func appendClass(class, s string) string {
+	if class != "" {
+		class += " "
+	}
+	class += s
+	return class
+}
+
+func whichTone(Syllable *SyllableType) string {
+	switch {
+	case Syllable.TrueHigh:
+		return "truehigh"
+	case Syllable.OptionalHigh:
+		return "optionalhigh"
+	case Syllable.OptionalLow:
+		return "optionallow"
+	default:
+	}
+	return ""
+}
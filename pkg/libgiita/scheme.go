@@ -0,0 +1,126 @@
+package libgiita
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// romanization schemes of the input
+const (
+	IAST = iota
+	Velthuis
+	HarvardKyoto
+	// AutoScheme detects Velthuis and Harvard-Kyoto, IAST is kept when in doubt
+	AutoScheme
+)
+
+// SchemeNames maps the names accepted by ParseScheme to the schemes
+var SchemeNames = map[string]int{
+	"iast":     IAST,
+	"velthuis": Velthuis,
+	"hk":       HarvardKyoto,
+	"auto":     AutoScheme,
+}
+
+var (
+	// only the letters used in Pali, the vowels written twice must be tried before the single ones
+	velthuisTable = map[string]string{
+		"aa": "ā", "ii": "ī", "uu": "ū", "AA": "Ā", "Aa": "Ā", "II": "Ī", "Ii": "Ī", "UU": "Ū", "Uu": "Ū",
+		".m": "ṃ", ".t": "ṭ", ".d": "ḍ", ".n": "ṇ", ".l": "ḷ", "~n": "ñ", "\"n": "ṅ",
+		".M": "Ṃ", ".T": "Ṭ", ".D": "Ḍ", ".N": "Ṇ", ".L": "Ḷ", "~N": "Ñ", "\"N": "Ṅ",
+	}
+	// Harvard-Kyoto is case sensitive: capitals are letters of their own
+	hkTable = map[string]string{
+		"A": "ā", "I": "ī", "U": "ū", "M": "ṃ", "T": "ṭ", "D": "ḍ", "N": "ṇ", "L": "ḷ", "G": "ṅ", "J": "ñ",
+	}
+	// capitals that are ordinary capitals at the start of a word in Harvard-Kyoto,
+	// as ṇ, ṃ, ḷ and ṅ never begin a Pali word e.g. "Namo"
+	hkNonInitials = "NMLG"
+	// letters that only exist in IAST
+	iastLetters = "āīūṭḍṇḷṅñṃṁĀĪŪṬḌṆḶṄÑṂṀ"
+	// the dot of an ellipsis never marks a letter
+	reVelthuis = regexp.MustCompile(`aa|ii|uu|(?:^|[^.])\.[mtdnl]|~n|"n`)
+	// a capital right after a lowercase letter, no text is written this way in IAST
+	reHK = regexp.MustCompile(`[a-z][AIUMTDNLGJ]`)
+	// a capitalised word whose initial could be a letter of Harvard-Kyoto e.g. "Tassa"
+	reCapitalised = regexp.MustCompile(`(?:^|[^\pL])[AIUTDJ][a-z]`)
+)
+
+// ParseScheme returns the scheme of the given name, see SchemeNames.
+func ParseScheme(name string) (int, error) {
+	scheme, ok := SchemeNames[strings.ToLower(name)]
+	if !ok {
+		return IAST, fmt.Errorf("unknown romanization scheme %q", name)
+	}
+	return scheme, nil
+}
+
+// DetectScheme returns the scheme src is obviously written in, IAST if
+// it has any IAST letter, if no other scheme is recognized or if
+// both Velthuis and Harvard-Kyoto look possible. Harvard-Kyoto is not
+// recognized in texts with capitalised words it would misread.
+func DetectScheme(src string) int {
+	if strings.ContainsAny(src, iastLetters) {
+		return IAST
+	}
+	velthuis := reVelthuis.MatchString(src)
+	hk := reHK.MatchString(src) && !reCapitalised.MatchString(src)
+	switch {
+	case velthuis && !hk:
+		return Velthuis
+	case hk && !velthuis:
+		return HarvardKyoto
+	}
+	return IAST
+}
+
+// toIAST converts src from the given scheme to IAST, keeping track of the offsets
+func (m offsetMap) toIAST(src string, scheme int) (string, offsetMap) {
+	if scheme == AutoScheme {
+		scheme = DetectScheme(src)
+	}
+	var table map[string]string
+	switch scheme {
+	case Velthuis:
+		table = velthuisTable
+	case HarvardKyoto:
+		table = hkTable
+	default:
+		return src, m
+	}
+	keys := make([]string, 0, len(table))
+	for k := range table {
+		keys = append(keys, k)
+	}
+	// longest match first
+	sort.Slice(keys, func(i, j int) bool {
+		return len(keys[i]) > len(keys[j]) || len(keys[i]) == len(keys[j]) && keys[i] < keys[j]
+	})
+	var ranges [][]int
+	var repls []string
+Outerloop:
+	for i := 0; i < len(src); {
+		for _, k := range keys {
+			// the dot of an ellipsis is punctuation e.g. "...tena"
+			if strings.HasPrefix(src[i:], k) && !(k[0] == '.' && (i > 0 && src[i-1] == '.')) &&
+				!(scheme == HarvardKyoto && strings.Contains(hkNonInitials, k) && wordStart(src, i)) {
+				ranges = append(ranges, []int{i, i + len(k)})
+				repls = append(repls, table[k])
+				i += len(k)
+				continue Outerloop
+			}
+		}
+		i += 1
+	}
+	return m.replaceFunc(src, ranges, func(i int) string { return repls[i] })
+}
+
+// wordStart reports whether src[i:] starts a word
+func wordStart(src string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(src[:i])
+	return i == 0 || !unicode.IsLetter(r)
+}
@@ -0,0 +1,111 @@
+package libgiita
+
+import "testing"
+
+func TestDetectScheme(t *testing.T) {
+	tests := []struct {
+		src  string
+		want int
+	}{
+		{"namo tassa bhagavato", IAST},
+		{"sammāsambuddhassa", IAST},
+		{"sammaasambuddhassa", Velthuis},
+		{"eva.m me suta.m", Velthuis},
+		{"sva~n~na", Velthuis},
+		{"gacchaami... namo", Velthuis},
+		{"sammAsambuddhassa", HarvardKyoto},
+		{"buddhaM saraNaM", HarvardKyoto},
+		{"NAMO TASSA", IAST},
+		{"Namo tassa... tena", IAST},
+		{"Namo buddhaM", HarvardKyoto},
+		// "Tassa" could be ṭassa
+		{"Tassa buddhaM", IAST},
+		{"namo. Idha buddhaM", IAST},
+		// both
+		{"sammaasambuddhassa buddhaM", IAST},
+		// IAST letters win
+		{"sammāsambuddhassa eva.m", IAST},
+	}
+	for _, tt := range tests {
+		if got := DetectScheme(tt.src); got != tt.want {
+			t.Errorf("DetectScheme(%q) = %d, want %d", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestScheme(t *testing.T) {
+	tests := []struct {
+		src    string
+		scheme int
+		want   string
+	}{
+		{"sammaasambuddhassa", Velthuis, "sammāsambuddhassa"},
+		{".thaana.m eva.m... \"nga ~na", Velthuis, "ṭhānaṁ evaṁ... ṅga ña"},
+		{"AAnanda", Velthuis, "Ānanda"},
+		{"sammAsambuddhassa buddhaM saraNaM", HarvardKyoto, "sammāsambuddhassa buddhaṁ saraṇaṁ"},
+		{"paJJA aGga", HarvardKyoto, "paññā aṅga"},
+		{"Namo tassa, Metta saraNaM", HarvardKyoto, "Namo tassa, Metta saraṇaṁ"},
+		{"Namo buddhaM", AutoScheme, "Namo buddhaṁ"},
+		{"sammAsambuddhassa", AutoScheme, "sammāsambuddhassa"},
+		{"sammaasambuddhassa", AutoScheme, "sammāsambuddhassa"},
+		{"sammaasambuddhassa", IAST, "sammaasambuddhassa"},
+	}
+	for _, tt := range tests {
+		doc, err := Process(tt.src, Options{Scheme: tt.scheme})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		for _, Paragraph := range doc.Paragraphs {
+			for _, Segment := range Paragraph {
+				for _, Syllable := range Segment {
+					got += Syllable.String()
+				}
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%q in scheme %d = %q, want %q", tt.src, tt.scheme, got, tt.want)
+		}
+	}
+}
+
+// without a scheme selected, texts looking like Velthuis or Harvard-Kyoto are left as they are
+func TestSchemeDefault(t *testing.T) {
+	for _, src := range []string{"namo tassa mcDonald", "the iPhone of Ānanda", "vacuum, bazaar", "buddhaM saraNaM"} {
+		doc, err := Process(src, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		for _, Paragraph := range doc.Paragraphs {
+			for _, Segment := range Paragraph {
+				got += Segment.String()
+			}
+		}
+		if got != src {
+			t.Errorf("%q = %q, want it unchanged", src, got)
+		}
+	}
+}
+
+func TestSchemeComments(t *testing.T) {
+	src := "[see .m and aa] buddha.m [NOTE: MN 1]"
+	doc, err := Process(src, Options{Scheme: AutoScheme, CmtMarks: "[:]"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.CmtsPara) != 1 || doc.CmtsPara[0] != "[see .m and aa] " ||
+		len(doc.CmtsSpan) != 1 || doc.CmtsSpan[0] != "[NOTE: MN 1]" {
+		t.Errorf("comments = %q %q", doc.CmtsPara, doc.CmtsSpan)
+	}
+	// after "buddha.m" became "buddhaṁ", the positions are still those of the source
+	for _, Syllable := range doc.Paragraphs[0][0] {
+		if Syllable.String() == "dhaṁ" {
+			if got, want := Syllable.Pos(), (Pos{19, 1, 20}); got != want {
+				t.Errorf("position of %q = %v, want %v", "dhaṁ", got, want)
+			}
+			return
+		}
+	}
+	t.Error(`syllable "dhaṁ" not found`)
+}
@@ -0,0 +1,200 @@
+package libgiita
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// scripts transliterated to IAST, see ThaiTranslit for the Thai script
+const (
+	Roman = iota
+	Devanagari
+	Sinhala
+	Myanmar
+)
+
+// ScriptNames maps the names accepted by ParseScript to the scripts
+var ScriptNames = map[string]int{
+	"roman":      Roman,
+	"devanagari": Devanagari,
+	"sinhala":    Sinhala,
+	"myanmar":    Myanmar,
+}
+
+// abugida describes a script whose consonants carry an inherent "a" unless
+// followed by a vowel sign or a virama. Keys are one or two runes long.
+type abugida struct {
+	consonants, vowels, vowelSigns map[string]string
+	// medials are consonants written as signs, the inherent vowel goes after them
+	medials map[string]string
+	// viramas remove the inherent vowel
+	viramas string
+	// others are the signs, digits and punctuation
+	others map[string]string
+}
+
+var abugidas = map[int]abugida{
+	Devanagari: {
+		consonants: map[string]string{
+			"क": "k", "ख": "kh", "ग": "g", "घ": "gh", "ङ": "ṅ",
+			"च": "c", "छ": "ch", "ज": "j", "झ": "jh", "ञ": "ñ",
+			"ट": "ṭ", "ठ": "ṭh", "ड": "ḍ", "ढ": "ḍh", "ण": "ṇ",
+			"त": "t", "थ": "th", "द": "d", "ध": "dh", "न": "n",
+			"प": "p", "फ": "ph", "ब": "b", "भ": "bh", "म": "m",
+			"य": "y", "र": "r", "ल": "l", "व": "v", "स": "s", "ह": "h", "ळ": "ḷ",
+		},
+		vowels: map[string]string{
+			"अ": "a", "आ": "ā", "इ": "i", "ई": "ī", "उ": "u", "ऊ": "ū", "ए": "e", "ओ": "o",
+		},
+		vowelSigns: map[string]string{
+			"ा": "ā", "ि": "i", "ी": "ī", "ु": "u", "ू": "ū", "े": "e", "ो": "o",
+		},
+		viramas: "्",
+		others: map[string]string{
+			"ं": "ṃ", "ँ": "ṃ", "।": ".", "॥": ".", "ऽ": "’", "‌": "", "‍": "",
+			"०": "0", "१": "1", "२": "2", "३": "3", "४": "4", "५": "5", "६": "6", "७": "7", "८": "8", "९": "9",
+		},
+	},
+	Sinhala: {
+		consonants: map[string]string{
+			"ක": "k", "ඛ": "kh", "ග": "g", "ඝ": "gh", "ඞ": "ṅ",
+			"ච": "c", "ඡ": "ch", "ජ": "j", "ඣ": "jh", "ඤ": "ñ",
+			"ට": "ṭ", "ඨ": "ṭh", "ඩ": "ḍ", "ඪ": "ḍh", "ණ": "ṇ",
+			"ත": "t", "ථ": "th", "ද": "d", "ධ": "dh", "න": "n",
+			"ප": "p", "ඵ": "ph", "බ": "b", "භ": "bh", "ම": "m",
+			"ය": "y", "ර": "r", "ල": "l", "ව": "v", "ස": "s", "හ": "h", "ළ": "ḷ",
+		},
+		vowels: map[string]string{
+			"අ": "a", "ආ": "ā", "ඉ": "i", "ඊ": "ī", "උ": "u", "ඌ": "ū", "එ": "e", "ඔ": "o",
+		},
+		vowelSigns: map[string]string{
+			"ා": "ā", "ි": "i", "ී": "ī", "ු": "u", "ූ": "ū", "ෙ": "e", "ො": "o", "ේ": "e", "ෝ": "o",
+			// decomposed forms of ො and ේ, that of ෝ ends with a virama
+			"\u0dd9\u0dcf": "o", "\u0dd9\u0dca": "e",
+		},
+		viramas: "්",
+		others: map[string]string{
+			"ං": "ṃ", "෴": ".", "‌": "", "‍": "",
+			"෦": "0", "෧": "1", "෨": "2", "෩": "3", "෪": "4", "෫": "5", "෬": "6", "෭": "7", "෮": "8", "෯": "9",
+		},
+	},
+	Myanmar: {
+		consonants: map[string]string{
+			"က": "k", "ခ": "kh", "ဂ": "g", "ဃ": "gh", "င": "ṅ",
+			"စ": "c", "ဆ": "ch", "ဇ": "j", "ဈ": "jh", "ဉ": "ñ",
+			"ဋ": "ṭ", "ဌ": "ṭh", "ဍ": "ḍ", "ဎ": "ḍh", "ဏ": "ṇ",
+			"တ": "t", "ထ": "th", "ဒ": "d", "ဓ": "dh", "န": "n",
+			"ပ": "p", "ဖ": "ph", "ဗ": "b", "ဘ": "bh", "မ": "m",
+			"ယ": "y", "ရ": "r", "လ": "l", "ဝ": "v", "သ": "s", "ဟ": "h", "ဠ": "ḷ",
+			// ligatures
+			"ည": "ññ", "ဿ": "ss",
+			// carrier of the initial vowels
+			"အ": "",
+		},
+		vowels: map[string]string{
+			"ဣ": "i", "ဤ": "ī", "ဥ": "u", "ဦ": "ū", "ဧ": "e", "ဩ": "o",
+		},
+		vowelSigns: map[string]string{
+			"ာ": "ā", "ါ": "ā", "ိ": "i", "ီ": "ī", "ု": "u", "ူ": "ū", "ေ": "e", "ော": "o", "ေါ": "o",
+		},
+		medials: map[string]string{
+			"ျ": "y", "ြ": "r", "ွ": "v", "ှ": "h",
+		},
+		// asat and the invisible virama of stacked consonants
+		viramas: "်္",
+		others: map[string]string{
+			"ံ": "ṃ", "့": "", "း": "", "၊": ",", "။": ".", "‌": "", "‍": "",
+			"၀": "0", "၁": "1", "၂": "2", "၃": "3", "၄": "4", "၅": "5", "၆": "6", "၇": "7", "၈": "8", "၉": "9",
+		},
+	},
+}
+
+// ParseScript returns the script of the given name, see ScriptNames.
+func ParseScript(name string) (int, error) {
+	script, ok := ScriptNames[strings.ToLower(name)]
+	if !ok {
+		return Roman, fmt.Errorf("unknown script %q", name)
+	}
+	return script, nil
+}
+
+// fromScript transliterates src from the given script to IAST, keeping track
+// of the offsets. The letters of other scripts are left as they are.
+func (m offsetMap) fromScript(src string, script int) (string, offsetMap) {
+	a, ok := abugidas[script]
+	if !ok {
+		return src, m
+	}
+	var ranges [][]int
+	var repls []string
+	// index in repls of the consonant whose inherent vowel is not decided yet, -1 if none
+	pending := -1
+	inherent := func() {
+		if pending != -1 {
+			repls[pending] += "a"
+			pending = -1
+		}
+	}
+	for i := 0; i < len(src); {
+		key, repl, kind := a.match(src[i:])
+		if key == "" {
+			inherent()
+			_, size := utf8.DecodeRuneInString(src[i:])
+			i += size
+			continue
+		}
+		switch kind {
+		case "consonant":
+			inherent()
+			pending = len(repls)
+		case "medial":
+			// the inherent vowel is that of the medial
+			if pending != -1 {
+				pending = len(repls)
+			}
+		case "vowelSign", "virama":
+			pending = -1
+		default:
+			inherent()
+		}
+		start := i
+		// as with the Thai script, the space before the danda is dropped
+		for kind == "other" && (repl == "." || repl == ",") && start > 0 && src[start-1] == ' ' {
+			start -= 1
+		}
+		ranges = append(ranges, []int{start, i + len(key)})
+		repls = append(repls, repl)
+		i += len(key)
+	}
+	inherent()
+	return m.replaceFunc(src, ranges, func(i int) string { return repls[i] })
+}
+
+// match returns the longest key of the tables at the beginning of s,
+// its transliteration and the kind of table it belongs to.
+func (a abugida) match(s string) (key, repl, kind string) {
+	_, size := utf8.DecodeRuneInString(s)
+	_, next := utf8.DecodeRuneInString(s[size:])
+	for _, n := range []int{size + next, size} {
+		if n > len(s) || n == 0 {
+			continue
+		}
+		key = s[:n]
+		if strings.Contains(a.viramas, key) && n == size {
+			return key, "", "virama"
+		}
+		for _, t := range [...]struct {
+			kind  string
+			table map[string]string
+		}{
+			{"consonant", a.consonants}, {"vowel", a.vowels}, {"vowelSign", a.vowelSigns},
+			{"medial", a.medials}, {"other", a.others},
+		} {
+			if repl, ok := t.table[key]; ok {
+				return key, repl, t.kind
+			}
+		}
+	}
+	return "", "", ""
+}
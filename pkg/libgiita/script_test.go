@@ -0,0 +1,31 @@
+package libgiita
+
+import "testing"
+
+func TestScript(t *testing.T) {
+	tests := []struct {
+		src    string
+		script int
+		want   string
+	}{
+		{"नमो तस्स भगवतो अरहतो सम्मासम्बुद्धस्स ।", Devanagari, "namo tassa bhagavato arahato sammāsambuddhassa."},
+		{"बुद्धं सरणं गच्छामि", Devanagari, "buddhaṃ saraṇaṃ gacchāmi"},
+		{"इति पि सो भगवा", Devanagari, "iti pi so bhagavā"},
+		{"नमो tassa १२", Devanagari, "namo tassa 12"},
+		{"නමො තස්ස භගවතො අරහතො සම්මාසම්බුද්ධස්ස", Sinhala, "namo tassa bhagavato arahato sammāsambuddhassa"},
+		{"නමෝ", Sinhala, "namo"},
+		{"බුද්ධං සරණං ගච්ඡාමි", Sinhala, "buddhaṃ saraṇaṃ gacchāmi"},
+		{"වාක්\u200dයය", Sinhala, "vākyaya"},
+		{"නමෝ \u0db1\u0db8\u0dd9\u0dcf\u0dca", Sinhala, "namo namo"},
+		{"နမော တဿ ဘဂဝတော အရဟတော သမ္မာသမ္ဗုဒ္ဓဿ။", Myanmar, "namo tassa bhagavato arahato sammāsambuddhassa."},
+		{"ဗုဒ္ဓံ သရဏံ ဂစ္ဆာမိ", Myanmar, "buddhaṃ saraṇaṃ gacchāmi"},
+		{"ပညာ ဗျာကရဏ သွာက္ခာတော", Myanmar, "paññā byākaraṇa svākkhāto"},
+		{"နမော", Roman, "နမော"},
+	}
+	for _, tt := range tests {
+		got, _ := newOffsetMap(tt.src).fromScript(tt.src, tt.script)
+		if got != tt.want {
+			t.Errorf("fromScript(%q, %d) = %q, want %q", tt.src, tt.script, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package libgiita
+
+import "strings"
+
+// Thai output styles, the same as those of pli.ThaiToRoman
+const (
+	ThaiColloquial = 1
+	ThaiPinthu     = 2
+)
+
+const pinthu = "ฺ"
+
+var (
+	thaiConsonants = map[string]string{
+		"k": "ก", "kh": "ข", "g": "ค", "gh": "ฆ", "ṅ": "ง",
+		"c": "จ", "ch": "ฉ", "j": "ช", "jh": "ฌ", "ñ": "ญ",
+		"ṭ": "ฏ", "ṭh": "ฐ", "ḍ": "ฑ", "ḍh": "ฒ", "ṇ": "ณ",
+		"t": "ต", "th": "ถ", "d": "ท", "dh": "ธ", "n": "น",
+		"p": "ป", "ph": "ผ", "b": "พ", "bh": "ภ", "m": "ม",
+		"y": "ย", "r": "ร", "l": "ล", "v": "ว", "s": "ส", "h": "ห", "ḷ": "ฬ",
+		"sm": "ส" + pinthu + "ม",
+	}
+	// vowels written after the consonants, "a" being implicit
+	thaiVowelSigns = map[string]string{"ā": "า", "i": "ิ", "ī": "ี", "u": "ุ", "ū": "ู"}
+	// vowels written before the consonants
+	thaiPrefixVowels = map[string]string{"e": "เ", "o": "โ"}
+)
+
+// ThaiSpelling returns the letters of the syllable in Thai script, in the
+// given style. The other units are left out, an empty string is returned if
+// there is no letter.
+//
+// In the pinthu style of the Thai Tipitaka the consonants without vowel take
+// a pinthu and the niggahita is written "ํ", in the colloquial style the
+// short a is written "ะ" or "ั" and the niggahita "ง".
+func (Syllable *SyllableType) ThaiSpelling(style int) string {
+	var onset, coda []string
+	vowel := ""
+	niggahita := false
+	for _, unit := range Syllable.Units {
+		s := strings.ToLower(unit.Str)
+		switch {
+		case contains(VowelTypes, unit.Type):
+			vowel = s
+		case (s == "ṁ" || s == "ṃ") && vowel != "":
+			niggahita = true
+		case unit.Type == Cons && vowel == "":
+			onset = append(onset, thaiConsonant(s))
+		case unit.Type == Cons:
+			coda = append(coda, thaiConsonant(s))
+		}
+	}
+	switch {
+	case vowel == "":
+		// consonants split from their cluster e.g. "s⸱vāk"
+		onset, coda = nil, onset
+	case len(onset) == 0:
+		// carrier of the initial vowels
+		onset = []string{"อ"}
+	}
+	sign := thaiVowelSigns[vowel]
+	switch {
+	case niggahita && style == ThaiColloquial:
+		coda = append([]string{"ง"}, coda...)
+	case niggahita && vowel == "i":
+		sign = "ึ"
+	case niggahita:
+		sign += "ํ"
+	}
+	if vowel == "a" && style == ThaiColloquial {
+		sign = "ะ"
+		if len(coda) > 0 {
+			sign = "ั"
+		}
+	}
+	var b strings.Builder
+	b.WriteString(thaiPrefixVowels[vowel])
+	b.WriteString(strings.Join(onset, pinthu))
+	b.WriteString(sign)
+	for _, c := range coda {
+		b.WriteString(c + pinthu)
+	}
+	s := b.String()
+	if style == ThaiColloquial {
+		s = strings.ReplaceAll(s, pinthu, "")
+	}
+	return s
+}
+
+// thaiConsonant returns the Thai letter of a roman consonant, s itself if there is none
+func thaiConsonant(s string) string {
+	if c, ok := thaiConsonants[s]; ok {
+		return c
+	}
+	return s
+}
+
+// isLetter reports whether the unit is spelled by ThaiSpelling
+func (unit UnitType) isLetter() bool {
+	return unit.Type == Cons || contains(VowelTypes, unit.Type)
+}
@@ -0,0 +1,56 @@
+package libgiita
+
+import (
+	"strings"
+	"testing"
+
+	pli "github.com/tassa-yoniso-manasi-karoto/pali-transliteration"
+)
+
+func TestThaiSpelling(t *testing.T) {
+	tests := []struct {
+		src                string
+		colloquial, pinthu string
+	}{
+		{"namo tassa bhagavato arahato sammāsambuddhassa",
+			"นะโม ตัสสะ ภะคะวะโต อะระหะโต สัมมาสัมพุทธัสสะ",
+			"นโม ตสฺส ภควโต อรหโต สมฺมาสมฺพุทฺธสฺส"},
+		{"buddhaṃ saraṇaṃ gacchāmi",
+			"พุทธัง สะระณัง คัจฉามิ",
+			"พุทฺธํ สรณํ คจฺฉามิ"},
+		{"svākkhāto saṅgho",
+			"สวากขาโต สังโฆ",
+			"สฺวากฺขาโต สงฺโฆ"},
+		{"imaṃ etaṃ", "อิมัง เอตัง", "อิมํ เอตํ"},
+		// following the syllabification "ta⸱smiṃ"
+		{"tasmiṃ", "ตะสมิง", "ตสฺมึ"},
+	}
+	for _, tt := range tests {
+		doc, err := Process(tt.src, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for style, want := range map[int]string{ThaiColloquial: tt.colloquial, ThaiPinthu: tt.pinthu} {
+			var b strings.Builder
+			for _, Paragraph := range doc.Paragraphs {
+				for _, Segment := range Paragraph {
+					for _, Syllable := range Segment {
+						b.WriteString(Syllable.ThaiSpelling(style))
+						for _, unit := range Syllable.Units {
+							if !unit.isLetter() {
+								b.WriteString(unit.Str)
+							}
+						}
+					}
+				}
+			}
+			if got := b.String(); got != want {
+				t.Errorf("%q in style %d:\n got %s\nwant %s", tt.src, style, got, want)
+			}
+			// the input transliteration reads it back
+			if back, src := pli.ThaiToRoman(want, style), strings.ReplaceAll(tt.src, "ṃ", "ṁ"); strings.ReplaceAll(back, "ṃ", "ṁ") != src {
+				t.Errorf("ThaiToRoman(%s, %d) = %q, want %q", want, style, back, src)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package libgiita
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// trie matches the longest unit at the beginning of a string. Its keys are
+// runes, case folded if fold is set.
+type trie struct {
+	root node
+	fold bool
+}
+
+type node struct {
+	children map[rune]*node
+	// type of the unit ending at this node, -1 if none
+	typ int
+}
+
+// built from the lists at initialization, later changes to them are not seen
+var (
+	// exact matches of all the lists, tried first
+	unitTrie = newTrie(false, LongVwls, ShortVwls, C, FrequentElisionMark, FrequentPunc, FrequentSpace, FrequentOther)
+	// case insensitive matches of the letters e.g. "Bh", "Ā"
+	letterTrie = newTrie(true, LongVwls, ShortVwls, C)
+)
+
+// newTrie adds the strings of each list with the list index as type. A string
+// present in several lists keeps the type of the first one.
+func newTrie(fold bool, lists ...[]string) *trie {
+	t := &trie{root: node{typ: -1}, fold: fold}
+	for typ, list := range lists {
+		for _, s := range list {
+			n := &t.root
+			for _, r := range s {
+				r = t.key(r)
+				child, ok := n.children[r]
+				if !ok {
+					if n.children == nil {
+						n.children = make(map[rune]*node)
+					}
+					child = &node{typ: -1}
+					n.children[r] = child
+				}
+				n = child
+			}
+			if n.typ == -1 {
+				n.typ = typ
+			}
+		}
+	}
+	return t
+}
+
+func (t *trie) key(r rune) rune {
+	if t.fold {
+		return foldRune(r)
+	}
+	return r
+}
+
+// match returns the length in bytes and the type of the longest unit
+// at the beginning of s, 0 if none.
+func (t *trie) match(s string) (size, typ int) {
+	n := &t.root
+	for i := 0; i < len(s); {
+		r, w := utf8.DecodeRuneInString(s[i:])
+		if n = n.children[t.key(r)]; n == nil {
+			break
+		}
+		i += w
+		if n.typ != -1 {
+			size, typ = i, n.typ
+		}
+	}
+	return
+}
+
+// foldRune returns the smallest rune of the case folding orbit of r, the
+// same for all the cases of a letter as (?i) in regular expressions.
+func foldRune(r rune) rune {
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// runLength returns the length in bytes of the longest prefix of s whose runes satisfy f
+func runLength(s string, f func(r rune) bool) (size int) {
+	for size < len(s) {
+		r, w := utf8.DecodeRuneInString(s[size:])
+		if r == utf8.RuneError && w == 1 || !f(r) {
+			break
+		}
+		size += w
+	}
+	return
+}
+
+// isSpace matches \s of RE2 (ReSpace), which unlike unicode.IsSpace is ASCII only
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r'
+}
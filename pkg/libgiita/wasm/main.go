@@ -0,0 +1,193 @@
+//go:build js && wasm
+
+// Command wasm exposes the libgiita pipeline to JavaScript so that the browser
+// version shares its implementation with the command line. Build with:
+//
+//	GOOS=js GOARCH=wasm go build -o giita.wasm ./wasm
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" .
+//
+// and serve giita.wasm, wasm_exec.js and index.html from the same directory.
+//
+// Once loaded it defines the global function giita(text, options) which
+// returns an object {output, error, ellipses}. The options are named after the
+// fields of Options and of the renderers, all are optional:
+//
+//	{format: "html", hint: 4.5, comments: "[:]", re: "", th: 0,
+//	 script: "roman", scheme: "iast", optionalLow: false, gloss: "",
+//	 exceptions: "", title: "giita", css: "", fontSize: 34, dark: false,
+//	 samyok: false, noto: false, train: false, newlines: 1,
+//	 optionalHigh: false, thaiOut: 0, interlinear: "below", formulas: "",
+//	 pe: false, compounds: "", lexicon: "", hintConfig: ""}
+//
+// format is one of "html", "txt" or "json", script one of ScriptNames and
+// scheme one of SchemeNames. exceptions holds additional exceptions in the
+// format of ParseExceptions. gloss is the prefix of the lines of the companion
+// text, laid out "below" or "side" according to interlinear. formulas holds
+// stock formulas in the format of ParseFormulas. compounds is "", "hint" or
+// "split" and lexicon holds stems added to DefaultLexicon. hintConfig is in
+// the format of ParseHintConfig.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	. "github.com/tassa-yoniso-manasi-karoto/giita/pkg/libgiita"
+)
+
+func main() {
+	js.Global().Set("giita", js.FuncOf(func(this js.Value, args []js.Value) any {
+		if len(args) == 0 || args[0].Type() != js.TypeString {
+			return result("", 0, fmt.Errorf("giita(text, options): text must be a string"))
+		}
+		opts := js.Undefined()
+		if len(args) > 1 {
+			opts = args[1]
+		}
+		output, ellipses, err := run(args[0].String(), optionGetter{opts})
+		return result(output, ellipses, err)
+	}))
+	// keep the exported function alive
+	select {}
+}
+
+func result(output string, ellipses int, err error) map[string]any {
+	res := map[string]any{"output": output, "ellipses": ellipses, "error": nil}
+	if err != nil {
+		res["error"] = err.Error()
+	}
+	return res
+}
+
+func run(src string, g optionGetter) (string, int, error) {
+	script, err := ParseScript(g.str("script", "roman"))
+	if err != nil {
+		return "", 0, err
+	}
+	scheme, err := ParseScheme(g.str("scheme", "iast"))
+	if err != nil {
+		return "", 0, err
+	}
+	opts := Options{
+		Script:       script,
+		Scheme:       scheme,
+		Hint:         g.float("hint", 4.5),
+		CmtMarks:     g.str("comments", ""),
+		Re:           g.str("re", ""),
+		ThaiTranslit: g.int("th", 0),
+		OptionalLow:  g.bool("optionalLow", false),
+		Gloss:        g.str("gloss", ""),
+		ExpandPe:     g.bool("pe", false),
+		Exceptions:   DefaultExceptions,
+	}
+	if x := g.str("exceptions", ""); x != "" {
+		UserExceptions, err := ParseExceptions(strings.NewReader(x))
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid exceptions: %w", err)
+		}
+		opts.Exceptions = DefaultExceptions.Merge(UserExceptions)
+	}
+	if x := g.str("formulas", ""); x != "" {
+		if opts.Formulas, err = ParseFormulas(strings.NewReader(x)); err != nil {
+			return "", 0, fmt.Errorf("invalid formulas: %w", err)
+		}
+	}
+	if x := g.str("hintConfig", ""); x != "" {
+		cfg, err := ParseHintConfig(x)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid hint configuration: %w", err)
+		}
+		opts.HintConfig = &cfg
+	}
+	compounds := g.str("compounds", "")
+	switch compounds {
+	case "":
+	case "hint", "split":
+		opts.Lexicon = DefaultLexicon
+		if x := g.str("lexicon", ""); x != "" {
+			UserLexicon, err := ParseLexicon(strings.NewReader(x))
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid lexicon: %w", err)
+			}
+			opts.Lexicon = DefaultLexicon.Merge(UserLexicon)
+		}
+	default:
+		return "", 0, fmt.Errorf("unknown compounds mode %q", compounds)
+	}
+	var r Renderer
+	switch format := g.str("format", "html"); format {
+	case "html", "htm":
+		r = HTMLRenderer{
+			Title:       g.str("title", "giita"),
+			CSS:         g.str("css", ""),
+			FontSize:    g.int("fontSize", 34),
+			Dark:        g.bool("dark", false),
+			Samyok:      g.bool("samyok", false),
+			Noto:        g.bool("noto", false),
+			Train:       g.bool("train", false),
+			Newlines:    g.int("newlines", 1),
+			Thai:        g.int("thaiOut", 0),
+			Interlinear: g.str("interlinear", "below"),
+			Split:       compounds == "split",
+		}
+	case "txt", "text":
+		r = TextRenderer{Newlines: g.int("newlines", 1), OptionalHigh: g.bool("optionalHigh", false), Thai: g.int("thaiOut", 0),
+			Split: compounds == "split"}
+	case "json":
+		r = JSONRenderer{Indent: "  "}
+	default:
+		return "", 0, fmt.Errorf("unknown format %q", format)
+	}
+	doc, err := Process(src, opts)
+	if err != nil {
+		return "", 0, err
+	}
+	var buf bytes.Buffer
+	if err = r.RenderDocument(&buf, doc); err != nil {
+		return "", 0, err
+	}
+	return buf.String(), doc.Ellipses, nil
+}
+
+// optionGetter reads the properties of the options object given by JavaScript
+type optionGetter struct {
+	v js.Value
+}
+
+func (g optionGetter) get(name string) (js.Value, bool) {
+	if g.v.Type() != js.TypeObject {
+		return js.Undefined(), false
+	}
+	v := g.v.Get(name)
+	return v, !v.IsUndefined() && !v.IsNull()
+}
+
+func (g optionGetter) str(name, def string) string {
+	if v, ok := g.get(name); ok {
+		return v.String()
+	}
+	return def
+}
+
+func (g optionGetter) bool(name string, def bool) bool {
+	if v, ok := g.get(name); ok {
+		return v.Truthy()
+	}
+	return def
+}
+
+func (g optionGetter) int(name string, def int) int {
+	if v, ok := g.get(name); ok && v.Type() == js.TypeNumber {
+		return v.Int()
+	}
+	return def
+}
+
+func (g optionGetter) float(name string, def float64) float64 {
+	if v, ok := g.get(name); ok && v.Type() == js.TypeNumber {
+		return v.Float()
+	}
+	return def
+}
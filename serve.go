@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+
+	. "github.com/tassa-yoniso-manasi-karoto/giita/pkg/libgiita"
+)
+
+//go:embed web/editor.htm
+var editorPage []byte
+
+var contentTypes = map[string]string{
+	"htm":  "text/html; charset=utf-8",
+	"txt":  "text/plain; charset=utf-8",
+	"json": "application/json; charset=utf-8",
+}
+
+// maximum size of a text submitted to the server
+const maxRequestSize = 10 << 20
+
+// serve starts an HTTP server providing a live editor at / and the
+// rendering endpoint POST /render.
+//
+// /render takes the text either as the "text" field of a form or as the raw
+// body of the request, and the options as form or query fields named after
+// the flags: format, hint, c, re, th, script, scheme, optionallow, optionalhigh,
+// d, samyok, noto, train, f, l, thaiout, interlinear, gloss, pe, compounds,
+// hintcfg. Options that are not given default to
+// those of the command line.
+func serve(addr string, opts Options) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", contentTypes["htm"])
+		w.Write(editorPage)
+	})
+	mux.HandleFunc("/render", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+		format, out, err := render(r, opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", contentTypes[format])
+		w.Write(out)
+	})
+	fmt.Fprintf(os.Stderr, "Listening on http://%s\n", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		die(exitFailure, err)
+	}
+}
+
+func render(r *http.Request, opts Options) (format string, out []byte, err error) {
+	var src string
+	mediatype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediatype {
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		if err = r.ParseMultipartForm(maxRequestSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return
+		}
+		src = r.FormValue("text")
+	default:
+		dat, err := io.ReadAll(r.Body)
+		if err != nil {
+			return "", nil, err
+		}
+		src = string(dat)
+		// query string only
+		r.ParseForm()
+	}
+	p := formParser{r: r}
+	format = p.str("format", *wantFormat)
+	switch format {
+	case "html", "htm":
+		format = "htm"
+	case "txt", "text":
+		format = "txt"
+	case "json":
+	default:
+		return "", nil, fmt.Errorf("unknown format %q", format)
+	}
+	opts.Hint = p.float("hint", opts.Hint)
+	opts.CmtMarks = p.str("c", opts.CmtMarks)
+	opts.Re = p.str("re", opts.Re)
+	opts.ThaiTranslit = p.int("th", opts.ThaiTranslit)
+	if name := p.str("script", ""); name != "" {
+		if opts.Script, err = ParseScript(name); err != nil {
+			return "", nil, err
+		}
+	}
+	if name := p.str("scheme", ""); name != "" {
+		if opts.Scheme, err = ParseScheme(name); err != nil {
+			return "", nil, err
+		}
+	}
+	opts.OptionalLow = p.bool("optionallow", opts.OptionalLow)
+	opts.ExpandPe = p.bool("pe", opts.ExpandPe)
+	interlinear := p.str("interlinear", *wantInterlinear)
+	switch interlinear {
+	case "":
+		opts.Gloss = ""
+	case "below", "side":
+		opts.Gloss = p.str("gloss", *glossPrefix)
+	default:
+		return "", nil, fmt.Errorf("unknown interlinear layout %q", interlinear)
+	}
+	compounds := p.str("compounds", *wantCompounds)
+	switch compounds {
+	case "":
+		opts.Lexicon = nil
+	case "hint", "split":
+		opts.Lexicon = lexicon
+	default:
+		return "", nil, fmt.Errorf("unknown compounds mode %q", compounds)
+	}
+	if s := p.str("hintcfg", ""); s != "" {
+		cfg, err := ParseHintConfig(s)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid hint configuration: %w", err)
+		}
+		opts.HintConfig = &cfg
+	}
+	opts.Debug = DebugType{}
+	rd := newRenderer("giita", format)
+	switch h := rd.(type) {
+	case HTMLRenderer:
+		h.Dark = p.bool("d", h.Dark)
+		h.Samyok = p.bool("samyok", h.Samyok)
+		h.Noto = p.bool("noto", h.Noto)
+		h.Train = p.bool("train", h.Train)
+		h.FontSize = p.int("f", h.FontSize)
+		h.Newlines = p.int("l", h.Newlines)
+		h.Thai = p.int("thaiout", h.Thai)
+		h.Interlinear = interlinear
+		h.Split = compounds == "split"
+		h.Meta = ""
+		rd = h
+	case TextRenderer:
+		h.Newlines = p.int("l", h.Newlines)
+		h.OptionalHigh = p.bool("optionalhigh", h.OptionalHigh)
+		h.Thai = p.int("thaiout", h.Thai)
+		h.Split = compounds == "split"
+		rd = h
+	}
+	if p.err != nil {
+		return "", nil, p.err
+	}
+	doc, err := Process(src, opts)
+	if err != nil {
+		return
+	}
+	var buf bytes.Buffer
+	err = rd.RenderDocument(&buf, doc)
+	return format, buf.Bytes(), err
+}
+
+// formParser reads typed values of the form, keeping the first error encountered
+type formParser struct {
+	r   *http.Request
+	err error
+}
+
+func (p *formParser) str(name, def string) string {
+	if _, ok := p.r.Form[name]; !ok {
+		return def
+	}
+	return p.r.Form.Get(name)
+}
+
+func (p *formParser) parse(name string, parse func(s string) error) {
+	if s, ok := p.r.Form[name]; ok && p.err == nil {
+		if err := parse(s[0]); err != nil {
+			p.err = fmt.Errorf("invalid value for %q: %w", name, err)
+		}
+	}
+}
+
+func (p *formParser) bool(name string, def bool) (b bool) {
+	b = def
+	p.parse(name, func(s string) (err error) {
+		b, err = strconv.ParseBool(s)
+		return
+	})
+	return
+}
+
+func (p *formParser) int(name string, def int) (i int) {
+	i = def
+	p.parse(name, func(s string) (err error) {
+		i, err = strconv.Atoi(s)
+		return
+	})
+	return
+}
+
+func (p *formParser) float(name string, def float64) (f float64) {
+	f = def
+	p.parse(name, func(s string) (err error) {
+		f, err = strconv.ParseFloat(s, 64)
+		return
+	})
+	return
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gookit/color"
+	. "github.com/tassa-yoniso-manasi-karoto/giita/pkg/libgiita"
+)
+
+// how often the watched files are checked for changes
+const watchInterval = 300 * time.Millisecond
+
+// number of times the output has been regenerated, polled by the preview page
+var generation int64
+
+// reloadScript reloads the preview page whenever the output is regenerated
+const reloadScript = `<script>
+let gen = "%d";
+setInterval(() => fetch("/generation").then(r => r.text()).then(g => {
+	if (g !== gen) location.reload();
+}).catch(() => {}), 500);
+</script>
+`
+
+// watch regenerates out whenever the input file or the -css file changes.
+// Errors are reported without stopping the watcher.
+func watch(in, out string, opts Options) {
+	if *wantPreview {
+		go preview(*addr, out)
+	}
+	files := []string{in}
+	if *UserCSSPath != "" {
+		files = append(files, *UserCSSPath)
+	}
+	fmt.Fprintln(os.Stderr, "Watching", strings.Join(files, ", "), "(Ctrl+C to quit)")
+	mtimes := make(map[string]time.Time)
+	for first := true; ; first = false {
+		changed := first
+		for _, f := range files {
+			var mtime time.Time
+			if fi, err := os.Stat(f); err == nil {
+				mtime = fi.ModTime()
+			}
+			if !mtime.Equal(mtimes[f]) {
+				mtimes[f] = mtime
+				changed = true
+			}
+		}
+		if changed {
+			regenerate(in, out, opts)
+		}
+		time.Sleep(watchInterval)
+	}
+}
+
+func regenerate(in, out string, opts Options) {
+	stamp := time.Now().Format("15:04:05")
+	if *UserCSSPath != "" {
+		dat, err := os.ReadFile(*UserCSSPath)
+		if err != nil {
+			color.Error.Println(stamp, fmt.Errorf("Could not read the CSS file: %w", err))
+			return
+		}
+		UserCSS = string(dat)
+	}
+	doc, err := convert(in, out, opts)
+	if err != nil {
+		color.Error.Println(stamp, err)
+		return
+	}
+	atomic.AddInt64(&generation, 1)
+	fmt.Fprintln(os.Stderr, stamp, "Regenerated", out)
+	if doc.Ellipses > 0 {
+		fmt.Fprintf(os.Stderr, "%s%d occurence(s) of '...' or '…', the chanting text could be incomplete%s\n",
+			Orange, doc.Ellipses, ANSIReset)
+	}
+}
+
+// preview serves the output file on addr as a page that reloads itself
+// when the output is regenerated.
+func preview(addr, out string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		gen := atomic.LoadInt64(&generation)
+		dat, err := os.ReadFile(out)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		page := string(dat)
+		if *wantFormat != "htm" {
+			page = "<!DOCTYPE html><html><head><meta charset=\"utf-8\"></head><body><pre>" +
+				html.EscapeString(page) + "</pre></body></html>"
+		}
+		script := fmt.Sprintf(reloadScript, gen)
+		if i := strings.LastIndex(page, "</body>"); i != -1 {
+			page = page[:i] + script + page[i:]
+		} else {
+			page += script
+		}
+		w.Header().Set("Content-Type", contentTypes["htm"])
+		w.Header().Set("Cache-Control", "no-store")
+		fmt.Fprint(w, page)
+	})
+	mux.HandleFunc("/generation", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		fmt.Fprint(w, atomic.LoadInt64(&generation))
+	})
+	fmt.Fprintf(os.Stderr, "Preview on http://%s\n", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		die(exitFailure, err)
+	}
+}